@@ -1,6 +1,8 @@
 package main
 
 import (
+	"flag"
+	"os"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -9,6 +11,21 @@ import (
 )
 
 func main() {
+	configPath := flag.String("config", "", "path to the config file, configs/config.yaml when empty")
+	storage := flag.String("storage", "", "postgres, memory or file, overrides storage.backend")
+	mode := flag.String("mode", "", "api or edge, overrides http_server.mode")
+	flag.Parse()
+
+	// The flags go through the environment so that config reloads keep them.
+	for env, value := range map[string]string{"STORAGE_BACKEND": *storage, "SERVER_MODE": *mode} {
+		if value == "" {
+			continue
+		}
+		if err := os.Setenv(env, value); err != nil {
+			logrus.Fatal(err)
+		}
+	}
+
 	logger := logrus.New()
 	formatter := &logrus.TextFormatter{
 		TimestampFormat: time.DateTime,
@@ -16,7 +33,14 @@ func main() {
 	}
 	logger.SetFormatter(formatter)
 
-	app := app.NewApp(logger)
+	app := app.NewApp(logger, *configPath)
+
+	if args := flag.Args(); len(args) > 0 && args[0] == "migrate" {
+		if err := app.Migrate(args[1:], os.Stdout); err != nil {
+			logger.Fatal(err)
+		}
+		return
+	}
 
 	if err := app.Run(); err != nil {
 		logger.Fatal(err)
@@ -11,98 +11,276 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 
 	authHandler "banner-service/internal/pkg/auth/http"
-	authRepository "banner-service/internal/pkg/auth/repository"
+	"banner-service/internal/pkg/auth/oidc"
 	authService "banner-service/internal/pkg/auth/sevice"
 	bannerHandler "banner-service/internal/pkg/banner/http"
-	bannerRepository "banner-service/internal/pkg/banner/repository"
 	bannerService "banner-service/internal/pkg/banner/service"
 	"banner-service/internal/pkg/cache"
 	"banner-service/internal/pkg/config"
+	"banner-service/internal/pkg/health"
+	"banner-service/internal/pkg/logging"
+	"banner-service/internal/pkg/metrics"
 	"banner-service/internal/pkg/middleware"
+	"banner-service/internal/pkg/ratelimit"
+	"banner-service/internal/pkg/snapshot"
+	"banner-service/internal/pkg/tracing"
 )
 
+const defaultConfigPath = "configs/config.yaml"
+
 type App struct {
-	logger *logrus.Logger
+	logger     *logrus.Logger
+	configPath string
+}
+
+func NewApp(logger *logrus.Logger, configPath string) *App {
+	return &App{logger: logger, configPath: configPath}
 }
 
-func NewApp(logger *logrus.Logger) *App {
-	return &App{logger: logger}
+func (a *App) configFile() (string, bool) {
+	if a.configPath == "" {
+		return defaultConfigPath, false
+	}
+	return a.configPath, true
 }
 
 func (a *App) Run() error {
-	wd, _ := os.Getwd()
-	cfg, err := config.Load(wd + "/configs/config.yaml")
+	path, required := a.configFile()
+	cfg, err := config.Load(path, required)
 	if err != nil {
-		a.logger.Fatalln(err)
+		a.logger.Error(err)
+		return err
 	}
 
-	db, err := pgxpool.New(context.Background(), fmt.Sprintf("postgres://%v:%v@%v:%v/%v?sslmode=disable",
-		cfg.DBUser,
-		cfg.DBPass,
-		cfg.DBHost,
-		cfg.DBPort,
-		cfg.DBName))
+	if err := logging.Configure(a.logger, cfg.LogLevel, cfg.LogFormat); err != nil {
+		a.logger.Error(err)
+		return err
+	}
 
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		Endpoint:    cfg.TracingEndpoint,
+		File:        cfg.TracingFile,
+		ServiceName: cfg.TracingServiceName,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
 	if err != nil {
-		err = fmt.Errorf("error happened in sql.Open: %w", err)
 		a.logger.Error(err)
 		return err
 	}
-	defer db.Close()
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			a.logger.Error("failed to shutdown tracing: ", err)
+		}
+	}()
+
+	if cfg.Mode == "edge" {
+		return a.runEdge(cfg)
+	}
 
-	if err = db.Ping(context.Background()); err != nil {
+	store, err := openStorage(context.Background(), cfg, a.logger)
+	if err != nil {
 		a.logger.Error(err)
 		return err
 	}
+	defer store.close()
 
-	rc := redis.NewClient(&redis.Options{
-		Addr:     cfg.RedisAddr,
-		Password: cfg.RedisPassword,
-	})
-	defer rc.Close()
+	// A standalone service keeps its rate limits in memory and does not cache, Redis is not used.
+	var rc *redis.Client
+	limitStore := ratelimit.Store(ratelimit.NewMemoryStore())
+	checks := store.checks
+	if !cfg.Standalone() {
+		rc = redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+		})
+		defer rc.Close()
+		rc.AddHook(tracing.RedisHook{})
 
-	tokenManager := jwter.New(cfg.JWTSecret, cfg.JWTTTL)
+		limitStore = ratelimit.NewFallbackStore(ratelimit.NewRedisStore(rc), limitStore, a.logger)
+		checks = append(checks, health.Check{Name: "redis", Ping: func(ctx context.Context) error {
+			return rc.Ping(ctx).Err()
+		}, Optional: true})
+	}
+
+	tokenManager, err := newTokenManager(cfg)
+	if err != nil {
+		a.logger.Error(err)
+		return err
+	}
 
-	cacheClient := cache.NewRedisClient(rc, cfg.RedisTTL)
+	appMetrics := metrics.New()
+	if store.pool != nil {
+		appMetrics.RegisterPool(store.pool)
+	}
 
-	bannerRepo := bannerRepository.NewBannerRepository(db)
-	bannerService := bannerService.NewBannerService(bannerRepo, cacheClient)
+	cacheClient := cache.NewRedisClient(rc, cfg.RedisTTL, appMetrics)
+
+	bannerService := bannerService.NewBannerService(store.banners, cacheClient)
 	bannerHandler := bannerHandler.NewBannerHandler(bannerService, a.logger)
+	bannerHandler.SetCacheTTL(clientCacheTTL(cfg))
+	if cfg.SnapshotKeyFile != "" {
+		key, err := snapshot.LoadPrivateKey(cfg.SnapshotKeyFile)
+		if err != nil {
+			err = fmt.Errorf("failed to load snapshot signing key: %w", err)
+			a.logger.Error(err)
+			return err
+		}
+		bannerHandler.WithSnapshotKey(key)
+	}
+
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	go store.run(bgCtx)
+	go appMetrics.TrackActiveBanners(bgCtx, 30*time.Second, bannerService.CountActiveBanners, a.logger)
+
+	signInGuard := ratelimit.NewSignInGuard(limitStore, signInLimits(cfg))
+
+	authService := authService.NewAuthService(store.users, signInGuard, a.logger)
+	var oidcHandler *authHandler.OIDCHandler
+	if cfg.OIDCEnabled {
+		provider, err := oidc.NewProvider(context.Background(), oidc.Config{
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopes,
+			GroupsClaim:  cfg.OIDCGroupsClaim,
+			AdminGroups:  cfg.OIDCAdminGroups,
+			TagGroups:    cfg.OIDCTagGroups,
+		})
+		if err != nil {
+			a.logger.Error(err)
+			return err
+		}
+
+		oidcHandler = authHandler.NewOIDCHandler(authService, provider, a.logger, tokenManager)
+	}
 
-	authRepo := authRepository.NewAuthRepository(db)
-	authService := authService.NewAuthService(authRepo)
 	authHandler := authHandler.NewAuthHandler(authService, a.logger, tokenManager)
 
 	mw := middleware.New(a.logger, tokenManager)
+	limiter := ratelimit.NewTokenBucket(limitStore, cfg.RateLimitDefault, cfg.RateLimitRoutes)
+	rl := middleware.NewRateLimit(a.logger, limiter)
+	cors := middleware.NewCORS(corsPolicy(cfg))
+
+	protected := func(onlyAdmin bool, h http.HandlerFunc) http.Handler {
+		return mw.Auth(onlyAdmin, rl.Limit(h))
+	}
+
+	checker := health.NewChecker(cfg.HealthTimeout, checks...)
+
+	root := mux.NewRouter()
+	root.Use(middleware.Trace, middleware.NewAccessLog(a.logger).Log, middleware.NewMetrics(appMetrics).Measure)
+	root.Handle("/metrics", appMetrics.Handler()).Methods("GET").Name("metrics")
+	root.HandleFunc("/healthz", checker.Live).Methods("GET").Name("healthz")
+	root.HandleFunc("/readyz", checker.Ready).Methods("GET").Name("readyz")
+	root.HandleFunc("/.well-known/jwks.json", authHandler.JWKS).Methods("GET").Name("jwks")
+
+	r := root.PathPrefix("/api").Subrouter()
+	r.Handle("/user_banner", protected(false, bannerHandler.GetBanner)).Methods("GET").Name("user_banner")
+	r.Handle("/banner/{id:[0-9]+}", protected(true, bannerHandler.GetBannerVersions)).Methods("GET").
+		Name("banner_versions")
+	r.Handle("/banner", protected(true, bannerHandler.GetBannerList)).Methods("GET").Name("banner_list")
+	r.Handle("/banner", protected(true, bannerHandler.AddBanner)).Methods("POST").Name("banner_create")
+	r.Handle("/banner/search", protected(true, bannerHandler.SearchBanners)).Methods("GET").Name("banner_search")
+	r.Handle("/banner/batch", protected(true, bannerHandler.AddBanners)).Methods("POST").Name("banner_batch")
+	r.Handle("/banner/snapshot", protected(true, bannerHandler.GetSnapshot)).Methods("GET").Name("banner_snapshot")
+	r.Handle("/banner/export", protected(true, bannerHandler.ExportBanners)).Methods("GET").Name("banner_export")
+	r.Handle("/banner/import", protected(true, bannerHandler.ImportBanners)).Methods("POST").Name("banner_import")
+	r.Handle("/banner/{id:[0-9]+}", protected(true, bannerHandler.UpdateBanner)).Methods("PATCH").
+		Name("banner_update")
+	r.Handle("/banner/{id:[0-9]+}", protected(true, bannerHandler.ChangeVersionBanner)).Methods("PUT").
+		Name("banner_change_version")
+	r.Handle("/banner/{id:[0-9]+}", protected(true, bannerHandler.DeleteBanner)).Methods("DELETE").
+		Name("banner_delete")
+	r.Handle("/sign_in", rl.Limit(http.HandlerFunc(authHandler.SignIn))).Methods("POST").Name("sign_in")
+	r.Handle("/sign_up", rl.Limit(http.HandlerFunc(authHandler.SignUp))).Methods("POST").Name("sign_up")
+
+	if oidcHandler != nil {
+		r.Handle("/oidc/login", rl.Limit(http.HandlerFunc(oidcHandler.Login))).Methods("GET").Name("oidc_login")
+		r.Handle("/oidc/callback", rl.Limit(http.HandlerFunc(oidcHandler.Callback))).Methods("GET").
+			Name("oidc_callback")
+	}
+
+	srv := newServer(cfg, cors.Handle(root))
+
+	reload := &reloader{
+		logger:   a.logger,
+		path:     path,
+		required: required,
+		cache:    cacheClient,
+		banners:  bannerHandler,
+		limiter:  limiter,
+		guard:    signInGuard,
+		cors:     cors,
+		current:  cfg,
+	}
+	reloadNow := func() {
+		if err := reload.Reload(); err != nil {
+			a.logger.Error("config reload rejected: ", err)
+		}
+	}
+	if cfg.ReloadInterval > 0 {
+		go config.Watch(bgCtx, path, cfg.ReloadInterval, reloadNow)
+	}
 
-	r := mux.NewRouter().PathPrefix("/api").Subrouter()
-	r.Handle("/user_banner", mw.Auth(false, http.HandlerFunc(bannerHandler.GetBanner))).Methods("GET")
-	r.Handle("/banner/{id:[0-9]+}", mw.Auth(true, http.HandlerFunc(bannerHandler.GetBannerVersions))).Methods("GET")
-	r.Handle("/banner", mw.Auth(true, http.HandlerFunc(bannerHandler.GetBannerList))).Methods("GET")
-	r.Handle("/banner", mw.Auth(true, http.HandlerFunc(bannerHandler.AddBanner))).Methods("POST")
-	r.Handle("/banner/{id:[0-9]+}", mw.Auth(true,
-		http.HandlerFunc(bannerHandler.UpdateBanner))).Methods("PATCH")
-	r.Handle("/banner/{id:[0-9]+}", mw.Auth(true,
-		http.HandlerFunc(bannerHandler.ChangeVersionBanner))).Methods("PUT")
-	r.Handle("/banner/{id:[0-9]+}", mw.Auth(true,
-		http.HandlerFunc(bannerHandler.DeleteBanner))).Methods("DELETE")
-	r.HandleFunc("/sign_in", authHandler.SignIn).Methods("POST")
-	r.HandleFunc("/sign_up", authHandler.SignUp).Methods("POST")
-
-	srv := http.Server{
-		Handler:           r,
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-bgCtx.Done():
+				return
+			case <-hup:
+				reloadNow()
+			}
+		}
+	}()
+
+	return a.serve(srv, checker, cfg.ShutdownDelay)
+}
+
+func newServer(cfg *config.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
 		Addr:              cfg.Address,
 		ReadTimeout:       cfg.Timeout,
 		WriteTimeout:      cfg.Timeout,
 		IdleTimeout:       cfg.IDleTimeout,
 		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
 	}
+}
 
+func newTokenManager(cfg *config.Config) (*jwter.Manager, error) {
+	tokenManager := jwter.New(cfg.JWTSecret, cfg.JWTTTL)
+	if len(cfg.JWTKeys) > 0 {
+		keys := make([]jwter.Key, 0, len(cfg.JWTKeys))
+		for _, k := range cfg.JWTKeys {
+			key, err := jwter.LoadKey(k.ID, k.PrivateKeyFile, k.ActiveFrom, k.ExpiresAt)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load jwt key %s: %w", k.ID, err)
+			}
+			keys = append(keys, key)
+		}
+
+		var err error
+		if tokenManager, err = jwter.NewWithKeys(keys, cfg.JWTTTL); err != nil {
+			return nil, err
+		}
+	}
+	tokenManager.WithValidation(cfg.JWTIssuer, cfg.JWTAudience, cfg.JWTLeeway)
+	return tokenManager, nil
+}
+
+// serve runs srv until the process is asked to stop, then fails the readiness checks for shutdownDelay
+// before shutting it down.
+func (a *App) serve(srv *http.Server, checker *health.Checker, shutdownDelay time.Duration) error {
 	quit := make(chan os.Signal, 1)
 
 	signal.Notify(quit, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
@@ -118,10 +296,13 @@ func (a *App) Run() error {
 	a.logger.Debug("handle quit chanel: ", sig.String())
 	a.logger.Info("server stopping...")
 
+	checker.Shutdown()
+	time.Sleep(shutdownDelay)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	if err = srv.Shutdown(ctx); err != nil {
+	if err := srv.Shutdown(ctx); err != nil {
 		a.logger.Error("server shutdown returned an err: ", err)
 		err = fmt.Errorf("error happened in srv.Shutdown: %w", err)
 		return err
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	bannerHandler "banner-service/internal/pkg/banner/http"
+	bannerService "banner-service/internal/pkg/banner/service"
+	"banner-service/internal/pkg/config"
+	"banner-service/internal/pkg/health"
+	"banner-service/internal/pkg/metrics"
+	"banner-service/internal/pkg/middleware"
+	"banner-service/internal/pkg/ratelimit"
+	"banner-service/internal/pkg/snapshot"
+)
+
+// runEdge serves /api/user_banner from the snapshots at cfg.EdgeSource, without Postgres or Redis. The node
+// is not ready until it has loaded a snapshot.
+func (a *App) runEdge(cfg *config.Config) error {
+	key, err := snapshot.LoadPublicKey(cfg.EdgePublicKeyFile)
+	if err != nil {
+		a.logger.Error(err)
+		return err
+	}
+
+	tokenManager, err := newTokenManager(cfg)
+	if err != nil {
+		a.logger.Error(err)
+		return err
+	}
+
+	table := snapshot.NewTable()
+	poller := snapshot.NewPoller(cfg.EdgeSource, key, table, cfg.EdgeLogin, cfg.EdgePassword, a.logger)
+	if err = poller.Poll(context.Background()); err != nil {
+		a.logger.Error("failed to load snapshot: ", err)
+	}
+
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	go poller.Run(bgCtx, cfg.EdgePollInterval)
+
+	appMetrics := metrics.New()
+	bannerHandler := bannerHandler.NewUserBannerHandler(bannerService.NewEdgeService(table), a.logger)
+	// Content changes when a newer snapshot is polled, clients may keep it for as long.
+	bannerHandler.SetCacheTTL(cfg.EdgePollInterval)
+
+	mw := middleware.New(a.logger, tokenManager)
+	limiter := ratelimit.NewTokenBucket(ratelimit.NewMemoryStore(), cfg.RateLimitDefault, cfg.RateLimitRoutes)
+	rl := middleware.NewRateLimit(a.logger, limiter)
+	cors := middleware.NewCORS(corsPolicy(cfg))
+
+	checker := health.NewChecker(cfg.HealthTimeout, health.Check{Name: "snapshot", Ping: table.Ping})
+
+	root := mux.NewRouter()
+	root.Use(middleware.Trace, middleware.NewAccessLog(a.logger).Log, middleware.NewMetrics(appMetrics).Measure)
+	root.Handle("/metrics", appMetrics.Handler()).Methods("GET").Name("metrics")
+	root.HandleFunc("/healthz", checker.Live).Methods("GET").Name("healthz")
+	root.HandleFunc("/readyz", checker.Ready).Methods("GET").Name("readyz")
+
+	r := root.PathPrefix("/api").Subrouter()
+	r.Handle("/user_banner", mw.Auth(false, rl.Limit(http.HandlerFunc(bannerHandler.GetBanner)))).Methods("GET").
+		Name("user_banner")
+
+	a.logger.Infof("edge node serving snapshots from %s", cfg.EdgeSource)
+	return a.serve(newServer(cfg, cors.Handle(root)), checker, cfg.ShutdownDelay)
+}
@@ -0,0 +1,165 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+
+	bannerRepository "banner-service/internal/pkg/banner/repository"
+	"banner-service/internal/pkg/config"
+	"banner-service/internal/pkg/migrate"
+	"banner-service/internal/pkg/replica"
+	"banner-service/internal/pkg/tracing"
+	"banner-service/migrations"
+)
+
+var errMigrateUsage = errors.New("usage: banner-service migrate up|down|status")
+
+// openDB runs afterConnect, when set, on every new connection of the pool.
+func openDB(ctx context.Context, cfg *config.Config,
+	afterConnect func(context.Context, *pgx.Conn) error) (*pgxpool.Pool, error) {
+	db, err := newPool(ctx, cfg, cfg.DSN(), afterConnect)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.Ping(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// openReplicas does not wait for the replicas, one that is down is left to the health checks of the router.
+func openReplicas(ctx context.Context, cfg *config.Config, primary *pgxpool.Pool,
+	logger *logrus.Logger) (*replica.Router, error) {
+	pools := make(map[string]*pgxpool.Pool, len(cfg.DBReplicas))
+	for _, address := range cfg.DBReplicas {
+		pool, err := newPool(ctx, cfg, cfg.ReplicaDSN(address), bannerRepository.Prepare)
+		if err != nil {
+			for _, p := range pools {
+				p.Close()
+			}
+			return nil, fmt.Errorf("replica %s: %w", address, err)
+		}
+		pools[address] = pool
+	}
+
+	reads := replica.NewRouter(primary, pools, logger)
+	reads.Check(ctx, cfg.HealthTimeout)
+	return reads, nil
+}
+
+func newPool(ctx context.Context, cfg *config.Config, dsn string,
+	afterConnect func(context.Context, *pgx.Conn) error) (*pgxpool.Pool, error) {
+	dbConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	dbConfig.ConnConfig.Tracer = tracing.PgxTracer{}
+	dbConfig.AfterConnect = afterConnect
+	dbConfig.MaxConns = cfg.DBMaxConns
+	dbConfig.MinConns = cfg.DBMinConns
+	dbConfig.MaxConnLifetime = cfg.DBMaxConnLifetime
+	dbConfig.MaxConnIdleTime = cfg.DBMaxConnIdleTime
+	dbConfig.HealthCheckPeriod = cfg.DBHealthCheckPeriod
+
+	db, err := pgxpool.NewWithConfig(ctx, dbConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error happened in sql.Open: %w", err)
+	}
+
+	return db, nil
+}
+
+// migrateUp uses a pool of its own, the statements prepared on the pool of the app need the tables it creates.
+func migrateUp(ctx context.Context, cfg *config.Config) ([]migrate.Migration, error) {
+	db, err := openDB(ctx, cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	m, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+	return m.Up(ctx)
+}
+
+// Migrate runs the migrate subcommand and writes its report to out.
+func (a *App) Migrate(args []string, out io.Writer) error {
+	if len(args) != 1 || (args[0] != "up" && args[0] != "down" && args[0] != "status") {
+		return errMigrateUsage
+	}
+
+	// Migrations only need the database, a fresh environment can run them before the rest is configured.
+	path, required := a.configFile()
+	cfg, err := config.LoadPostgres(path, required)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	db, err := openDB(ctx, cfg, nil)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+		for _, mig := range applied {
+			fmt.Fprintf(out, "applied %d_%s\n", mig.Version, mig.Name)
+		}
+	case "down":
+		reverted, err := m.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			fmt.Fprintln(out, "no applied migrations")
+		} else {
+			fmt.Fprintf(out, "reverted %d_%s\n", reverted.Version, reverted.Name)
+		}
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return tw.Flush()
+	default:
+		return errMigrateUsage
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	bannerHandler "banner-service/internal/pkg/banner/http"
+	"banner-service/internal/pkg/cache"
+	"banner-service/internal/pkg/config"
+	"banner-service/internal/pkg/logging"
+	"banner-service/internal/pkg/middleware"
+	"banner-service/internal/pkg/ratelimit"
+)
+
+var errRestartRequired = errors.New("config changes require a restart")
+
+// reloader re-reads the config and applies the fields tagged reload:"true" to running components.
+type reloader struct {
+	logger   *logrus.Logger
+	path     string
+	required bool
+
+	cache   *cache.RedisClient
+	banners *bannerHandler.BannerHandler
+	limiter *ratelimit.TokenBucket
+	guard   *ratelimit.SignInGuard
+	cors    *middleware.MwCORS
+
+	mu      sync.Mutex
+	current *config.Config
+}
+
+func (rl *reloader) Reload() error {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	updated, err := config.Load(rl.path, rl.required)
+	if err != nil {
+		return err
+	}
+
+	changes := config.Diff(rl.current, updated)
+	if len(changes) == 0 {
+		rl.logger.Info("config reloaded, nothing changed")
+		return nil
+	}
+
+	var rejected []string
+	for _, c := range changes {
+		if !c.Reloadable {
+			rejected = append(rejected, c.String())
+		}
+	}
+	if len(rejected) > 0 {
+		return fmt.Errorf("%w: %s", errRestartRequired, strings.Join(rejected, "; "))
+	}
+
+	rl.apply(updated)
+	rl.current = updated
+
+	for _, c := range changes {
+		rl.logger.WithField("change", c.String()).Info("config reloaded")
+	}
+
+	return nil
+}
+
+func (rl *reloader) apply(cfg *config.Config) {
+	_ = logging.Configure(rl.logger, cfg.LogLevel, cfg.LogFormat)
+	rl.cache.SetTTL(cfg.RedisTTL)
+	rl.banners.SetCacheTTL(clientCacheTTL(cfg))
+	rl.limiter.SetLimits(cfg.RateLimitDefault, cfg.RateLimitRoutes)
+	rl.guard.SetLimits(signInLimits(cfg))
+	rl.cors.SetPolicy(corsPolicy(cfg))
+}
+
+// clientCacheTTL is zero for a standalone service, it has no Redis and the banners are not cached on the server.
+func clientCacheTTL(cfg *config.Config) time.Duration {
+	if cfg.Standalone() {
+		return 0
+	}
+	return cfg.RedisTTL
+}
+
+func signInLimits(cfg *config.Config) ratelimit.SignInLimits {
+	return ratelimit.SignInLimits{
+		LoginAttempts:    cfg.SignInLoginAttempts,
+		IPAttempts:       cfg.SignInIPAttempts,
+		Window:           cfg.SignInWindow,
+		LockoutThreshold: cfg.SignInLockoutThreshold,
+		LockoutDuration:  cfg.SignInLockoutDuration,
+	}
+}
+
+func corsPolicy(cfg *config.Config) middleware.CORSPolicy {
+	return middleware.CORSPolicy{
+		AllowedOrigins: cfg.CORSAllowedOrigins,
+		AllowedMethods: cfg.CORSAllowedMethods,
+		AllowedHeaders: cfg.CORSAllowedHeaders,
+		MaxAge:         cfg.CORSMaxAge,
+	}
+}
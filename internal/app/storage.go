@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/auth"
+	authRepository "banner-service/internal/pkg/auth/repository"
+	"banner-service/internal/pkg/banner"
+	bannerRepository "banner-service/internal/pkg/banner/repository"
+	"banner-service/internal/pkg/config"
+	"banner-service/internal/pkg/filestore"
+	"banner-service/internal/pkg/health"
+	"banner-service/internal/pkg/memstore"
+)
+
+// storage is the backend picked by storage.backend. pool is nil unless it is Postgres.
+type storage struct {
+	banners banner.BannerRepository
+	users   auth.Repository
+	checks  []health.Check
+	pool    *pgxpool.Pool
+	run     func(ctx context.Context)
+	close   func()
+}
+
+func openStorage(ctx context.Context, cfg *config.Config, logger *logrus.Logger) (*storage, error) {
+	switch cfg.StorageBackend {
+	case "memory":
+		store := memstore.New()
+		if err := seedStore(store, cfg); err != nil {
+			return nil, err
+		}
+		logger.Warn("banners and users are kept in memory and are lost when the service stops")
+		return memoryStorage(store, func(context.Context) {}, func() {}), nil
+	case "file":
+		store := memstore.New()
+		files, err := filestore.Open(cfg.StorageDir, store, cfg.StorageCompactEvery, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open storage in %s: %w", cfg.StorageDir, err)
+		}
+		if err = seedStore(store, cfg); err != nil {
+			_ = files.Close()
+			return nil, err
+		}
+		return memoryStorage(store, files.Run, func() {
+			if err := files.Close(); err != nil {
+				logger.Error("failed to close storage: ", err)
+			}
+		}), nil
+	}
+
+	if cfg.DBMigrate {
+		applied, err := migrateUp(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range applied {
+			logger.Infof("applied migration %d_%s", m.Version, m.Name)
+		}
+	}
+
+	db, err := openDB(ctx, cfg, bannerRepository.Prepare)
+	if err != nil {
+		return nil, err
+	}
+
+	reads, err := openReplicas(ctx, cfg, db, logger)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &storage{
+		banners: bannerRepository.NewBannerRepository(db).WithReplicas(reads),
+		users:   authRepository.NewAuthRepository(db),
+		checks: []health.Check{
+			{Name: "postgres", Ping: db.Ping},
+			{Name: "postgres_replicas", Ping: reads.Ping, Optional: true},
+		},
+		pool: db,
+		run: func(ctx context.Context) {
+			reads.Run(ctx, cfg.DBReplicaCheckPeriod)
+		},
+		close: func() {
+			reads.Close()
+			db.Close()
+		},
+	}, nil
+}
+
+func memoryStorage(store *memstore.Store, run func(context.Context), closeFn func()) *storage {
+	return &storage{
+		banners: bannerRepository.NewMemoryBannerRepository(store),
+		users:   authRepository.NewMemoryAuthRepository(store),
+		run:     run,
+		close:   closeFn,
+	}
+}
+
+// seedStore creates the tags and features the seed of the database would and the admin of the config
+// unless there is a user with its login.
+func seedStore(store *memstore.Store, cfg *config.Config) error {
+	for id := 1; id <= cfg.StorageTags; id++ {
+		store.AddTags(id)
+	}
+	for id := 1; id <= cfg.StorageFeatures; id++ {
+		store.AddFeatures(id)
+	}
+
+	if cfg.StorageAdminPassword == "" {
+		return nil
+	}
+	err := store.Write(func(tx *memstore.Tx) error {
+		if _, ok := tx.User(cfg.StorageAdminLogin); ok {
+			return nil
+		}
+		return tx.PutUser(models.User{
+			UserID:   tx.NextUserID(),
+			Login:    cfg.StorageAdminLogin,
+			Password: cfg.StorageAdminPassword,
+			IsAdmin:  true,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create admin %s: %w", cfg.StorageAdminLogin, err)
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"banner-service/internal/pkg/client"
+)
+
+var errNoContent = errors.New("either --content or --content-file is required")
+
+type bannerFlags struct {
+	tags        []int
+	feature     int
+	content     string
+	contentFile string
+	active      bool
+}
+
+func (f *bannerFlags) register(cmd *cobra.Command) {
+	cmd.Flags().IntSliceVar(&f.tags, "tag", nil, "tag id, repeat or separate with commas")
+	cmd.Flags().IntVar(&f.feature, "feature", 0, "feature id")
+	cmd.Flags().StringVar(&f.content, "content", "", "banner content as JSON")
+	cmd.Flags().StringVar(&f.contentFile, "content-file", "", "file with banner content as JSON")
+	cmd.Flags().BoolVar(&f.active, "active", true, "whether the banner is shown to users")
+}
+
+// payload includes only the flags that were set, so that update leaves the rest of the banner as is.
+func (f *bannerFlags) payload(cmd *cobra.Command) (json.RawMessage, error) {
+	p := map[string]interface{}{}
+
+	if cmd.Flags().Changed("tag") {
+		p["tag_ids"] = f.tags
+	}
+	if cmd.Flags().Changed("feature") {
+		p["feature_id"] = f.feature
+	}
+	if cmd.Flags().Changed("active") || cmd.Name() == "create" {
+		p["is_active"] = f.active
+	}
+
+	content := []byte(f.content)
+	if f.contentFile != "" {
+		var err error
+		if content, err = os.ReadFile(f.contentFile); err != nil {
+			return nil, err
+		}
+	}
+	if len(content) > 0 {
+		if !json.Valid(content) {
+			return nil, errors.New("banner content is not valid JSON")
+		}
+		p["content"] = json.RawMessage(content)
+	}
+
+	return json.Marshal(p)
+}
+
+func newBannerCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{Use: "banner", Short: "Manage banners through the API"}
+	cmd.AddCommand(
+		newBannerListCommand(o),
+		newBannerGetCommand(o),
+		newBannerCreateCommand(o),
+		newBannerUpdateCommand(o),
+		newBannerDeleteCommand(o),
+		newBannerVersionsCommand(o),
+		newBannerRollbackCommand(o),
+		newBannerExportCommand(o),
+		newBannerImportCommand(o),
+	)
+	return cmd
+}
+
+func newBannerListCommand(o *options) *cobra.Command {
+	var filter client.BannerFilter
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List banners",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			api, err := o.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			list, err := api.ListBanners(cmd.Context(), filter)
+			if err != nil {
+				return err
+			}
+			banners := list.Banners
+
+			t := &table{header: []string{"ID", "TAGS", "FEATURE", "ACTIVE", "UPDATED", "CONTENT"}}
+			for _, b := range banners {
+				t.add(b.BannerID, joinInts(b.TagIDs), b.FeatureID, b.IsActive, b.UpdatedAt.Format(time.DateTime),
+					truncate(string(b.Content), 60))
+			}
+			if err = o.render(banners, t); err != nil {
+				return err
+			}
+
+			// Stderr keeps the json and yaml output parsable.
+			fmt.Fprintf(cmd.ErrOrStderr(), "%d of %d banners\n", len(banners), list.Total)
+			if list.NextCursor != "" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "next page: --cursor %s\n", list.NextCursor)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&filter.TagID, "tag", 0, "only banners with this tag")
+	cmd.Flags().IntVar(&filter.FeatureID, "feature", 0, "only banners with this feature")
+	cmd.Flags().IntVar(&filter.Limit, "limit", 0, "maximum number of banners")
+	cmd.Flags().IntVar(&filter.Offset, "offset", 0, "number of banners to skip")
+	cmd.Flags().StringVar(&filter.Sort, "sort", "", "banner_id, created_at or updated_at")
+	cmd.Flags().BoolVar(&filter.Desc, "desc", false, "sort in descending order")
+	cmd.Flags().StringVar(&filter.Cursor, "cursor", "", "continue from the cursor printed with the previous page")
+
+	return cmd
+}
+
+func newBannerGetCommand(o *options) *cobra.Command {
+	var (
+		tag, feature int
+		last         bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "Show the banner a user with the tag sees for the feature",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			api, err := o.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			content, err := api.UserBanner(cmd.Context(), tag, feature, last)
+			if err != nil {
+				return err
+			}
+
+			t := &table{header: []string{"CONTENT"}}
+			t.add(string(content))
+			return o.render(content, t)
+		},
+	}
+	cmd.Flags().IntVar(&tag, "tag", 0, "tag id")
+	cmd.Flags().IntVar(&feature, "feature", 0, "feature id")
+	cmd.Flags().BoolVar(&last, "last", false, "bypass the cache")
+	_ = cmd.MarkFlagRequired("tag")
+	_ = cmd.MarkFlagRequired("feature")
+
+	return cmd
+}
+
+func newBannerCreateCommand(o *options) *cobra.Command {
+	var f bannerFlags
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a banner",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if f.content == "" && f.contentFile == "" {
+				return errNoContent
+			}
+			payload, err := f.payload(cmd)
+			if err != nil {
+				return err
+			}
+
+			api, err := o.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			id, err := api.CreateBanner(cmd.Context(), payload)
+			if err != nil {
+				return err
+			}
+
+			t := &table{header: []string{"ID"}}
+			t.add(id)
+			return o.render(map[string]int{"banner_id": id}, t)
+		},
+	}
+	f.register(cmd)
+	_ = cmd.MarkFlagRequired("tag")
+	_ = cmd.MarkFlagRequired("feature")
+
+	return cmd
+}
+
+func newBannerUpdateCommand(o *options) *cobra.Command {
+	var f bannerFlags
+
+	cmd := &cobra.Command{
+		Use:   "update ID",
+		Short: "Update the given fields of a banner, creating a new version",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := intArg(args[0], "banner id")
+			if err != nil {
+				return err
+			}
+			payload, err := f.payload(cmd)
+			if err != nil {
+				return err
+			}
+
+			api, err := o.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if err = api.UpdateBanner(cmd.Context(), id, payload); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(o.out, "banner %d updated\n", id)
+			return nil
+		},
+	}
+	f.register(cmd)
+
+	return cmd
+}
+
+func newBannerDeleteCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete ID",
+		Short: "Delete a banner",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := intArg(args[0], "banner id")
+			if err != nil {
+				return err
+			}
+
+			api, err := o.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if err = api.DeleteBanner(cmd.Context(), id); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(o.out, "banner %d deleted\n", id)
+			return nil
+		},
+	}
+}
+
+func newBannerVersionsCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "versions ID",
+		Short: "List the versions of a banner",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := intArg(args[0], "banner id")
+			if err != nil {
+				return err
+			}
+
+			api, err := o.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			versions, err := api.BannerVersions(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+
+			t := &table{header: []string{"VERSION", "CURRENT", "UPDATED", "CONTENT"}}
+			cur := versions.CurrentVersion
+			t.add(cur.Version, true, cur.UpdatedAt.Format(time.DateTime), truncate(string(cur.Content), 60))
+			for _, v := range versions.OldVersions {
+				t.add(v.Version, false, v.UpdatedAt.Format(time.DateTime), truncate(string(v.Content), 60))
+			}
+			return o.render(versions, t)
+		},
+	}
+}
+
+func newBannerRollbackCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rollback ID VERSION",
+		Short: "Make an earlier version of a banner current",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := intArg(args[0], "banner id")
+			if err != nil {
+				return err
+			}
+			version, err := intArg(args[1], "version")
+			if err != nil {
+				return err
+			}
+
+			api, err := o.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if err = api.ChangeBannerVersion(cmd.Context(), id, version); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(o.out, "banner %d rolled back to version %d\n", id, version)
+			return nil
+		},
+	}
+}
+
+func joinInts(ids []int) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = fmt.Sprint(id)
+	}
+	return strings.Join(s, ",")
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ilyakaznacheev/cleanenv"
+)
+
+const defaultProfile = "default"
+
+// Profile holds the credentials of one environment. Environment variables override the selected profile.
+type Profile struct {
+	URL      string `yaml:"url" env:"BANNERCTL_URL"`
+	Login    string `yaml:"login" env:"BANNERCTL_LOGIN"`
+	Password string `yaml:"password" env:"BANNERCTL_PASSWORD"`
+	Token    string `yaml:"token" env:"BANNERCTL_TOKEN"`
+	// Config is the service config used by commands that go to the database directly.
+	Config string `yaml:"config" env:"BANNERCTL_CONFIG"`
+}
+
+type profiles struct {
+	Current  string             `yaml:"current"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+func profilesPath() string {
+	if path := os.Getenv("BANNERCTL_PROFILES"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "bannerctl", "profiles.yaml")
+}
+
+// loadProfile reads the named profile, or the file's current one when name is empty, then applies env overrides.
+func loadProfile(path, name string) (Profile, error) {
+	var p Profile
+
+	if path != "" {
+		var all profiles
+		err := cleanenv.ReadConfig(path, &all)
+		switch {
+		case err == nil:
+			if name == "" {
+				name = all.Current
+			}
+			if name == "" {
+				name = defaultProfile
+			}
+			selected, ok := all.Profiles[name]
+			if !ok && name != defaultProfile {
+				return Profile{}, fmt.Errorf("profile %q not found in %s", name, path)
+			}
+			p = selected
+		case errors.Is(err, os.ErrNotExist) && name == "":
+		default:
+			return Profile{}, fmt.Errorf("cannot read profiles %s: %w", path, err)
+		}
+	}
+
+	if err := cleanenv.ReadEnv(&p); err != nil {
+		return Profile{}, err
+	}
+	if p.URL == "" {
+		p.URL = "http://localhost:8080"
+	}
+
+	return p, nil
+}
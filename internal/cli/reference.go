@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"banner-service/internal/pkg/admin"
+)
+
+type idOps struct {
+	list   func(admin.Repository, context.Context) ([]int, error)
+	create func(admin.Repository, context.Context, int) error
+	remove func(admin.Repository, context.Context, int) error
+}
+
+// newIDCommand builds the tag and feature commands, which only differ in the repository methods they call.
+func newIDCommand(o *options, kind string) *cobra.Command {
+	ops := idOps{admin.Repository.ListTags, admin.Repository.CreateTag, admin.Repository.DeleteTag}
+	if kind == "feature" {
+		ops = idOps{admin.Repository.ListFeatures, admin.Repository.CreateFeature, admin.Repository.DeleteFeature}
+	}
+
+	cmd := &cobra.Command{Use: kind, Short: fmt.Sprintf("Manage %ss in the database", kind)}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: fmt.Sprintf("List %ss", kind),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			repo, err := o.repository(cmd.Context())
+			if err != nil {
+				return err
+			}
+			ids, err := ops.list(repo, cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			t := &table{header: []string{"ID"}}
+			for _, id := range ids {
+				t.add(id)
+			}
+			return o.render(ids, t)
+		},
+	})
+
+	for _, c := range []struct {
+		use, short, done string
+		run              func(admin.Repository, context.Context, int) error
+	}{
+		{"create", "Create", "created", ops.create},
+		{"delete", "Delete", "deleted", ops.remove},
+	} {
+		c := c
+		cmd.AddCommand(&cobra.Command{
+			Use:   c.use + " ID",
+			Short: fmt.Sprintf("%s a %s", c.short, kind),
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				id, err := intArg(args[0], kind+" id")
+				if err != nil {
+					return err
+				}
+				repo, err := o.repository(cmd.Context())
+				if err != nil {
+					return err
+				}
+				if err = c.run(repo, cmd.Context(), id); err != nil {
+					return fmt.Errorf("%s %d: %w", kind, id, err)
+				}
+
+				fmt.Fprintf(o.out, "%s %d %s\n", kind, id, c.done)
+				return nil
+			},
+		})
+	}
+
+	return cmd
+}
@@ -0,0 +1,141 @@
+// Package cli implements bannerctl, the operator command line for the banner service.
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+
+	"banner-service/internal/pkg/admin"
+	adminRepository "banner-service/internal/pkg/admin/repository"
+	"banner-service/internal/pkg/client"
+	"banner-service/internal/pkg/config"
+)
+
+type options struct {
+	out      io.Writer
+	profiles string
+	profile  string
+	url      string
+	output   string
+
+	api  *client.Client
+	db   *pgxpool.Pool
+	repo admin.Repository
+}
+
+func NewRootCommand(out io.Writer) *cobra.Command {
+	o := &options{out: out}
+
+	root := &cobra.Command{
+		Use:           "bannerctl",
+		Short:         "Manage banners, tags, features and users of the banner service",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPostRun: func(*cobra.Command, []string) {
+			if o.db != nil {
+				o.db.Close()
+			}
+		},
+	}
+	root.SetOut(out)
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&o.profiles, "profiles", profilesPath(), "profiles file")
+	flags.StringVarP(&o.profile, "profile", "p", "", "profile to use, the file's current profile when empty")
+	flags.StringVar(&o.url, "url", "", "API base URL, overrides the profile")
+	flags.StringVarP(&o.output, "output", "o", outputTable, "output format: table or json")
+
+	root.AddCommand(
+		newBannerCommand(o),
+		newIDCommand(o, "tag"),
+		newIDCommand(o, "feature"),
+		newUserCommand(o),
+	)
+
+	return root
+}
+
+// client signs in lazily so that commands going to the database do not need API credentials.
+func (o *options) client(ctx context.Context) (*client.Client, error) {
+	if o.api != nil {
+		return o.api, nil
+	}
+
+	p, err := loadProfile(o.profiles, o.profile)
+	if err != nil {
+		return nil, err
+	}
+	if o.url != "" {
+		p.URL = o.url
+	}
+
+	api := client.New(p.URL, nil)
+	if p.Token != "" {
+		api.SetToken(p.Token)
+	} else if err = api.SignIn(ctx, p.Login, p.Password); err != nil {
+		return nil, err
+	}
+
+	o.api = api
+	return api, nil
+}
+
+func (o *options) repository(ctx context.Context) (admin.Repository, error) {
+	if o.repo != nil {
+		return o.repo, nil
+	}
+
+	p, err := loadProfile(o.profiles, o.profile)
+	if err != nil {
+		return nil, err
+	}
+	path, required := p.Config, true
+	if path == "" {
+		path, required = "configs/config.yaml", false
+	}
+
+	cfg, err := config.LoadPostgres(path, required)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := pgxpool.New(ctx, cfg.DSN())
+	if err != nil {
+		return nil, err
+	}
+	// pgxpool connects lazily, a wrong DSN would otherwise surface as an error of the first query.
+	if err = db.Ping(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cannot connect to the database: %w", err)
+	}
+	o.db = db
+
+	o.repo = adminRepository.NewAdminRepository(o.db)
+	return o.repo, nil
+}
+
+func (o *options) render(value interface{}, t *table) error {
+	return render(o.out, o.output, value, t)
+}
+
+func intArg(s, name string) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, &argError{name: name, value: s}
+	}
+	return v, nil
+}
+
+type argError struct {
+	name  string
+	value string
+}
+
+func (e *argError) Error() string {
+	return "invalid " + e.name + " " + strconv.Quote(e.value) + ", expected a number"
+}
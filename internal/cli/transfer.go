@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"banner-service/internal/pkg/client"
+)
+
+func newBannerExportCommand(o *options) *cobra.Command {
+	var (
+		format, file string
+		versions     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export every banner as JSON Lines or CSV",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			api, err := o.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			w := o.out
+			if file != "" {
+				f, err := os.Create(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+
+			return api.ExportBanners(cmd.Context(), format, versions, w)
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "jsonl", "jsonl or csv")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "write to the file instead of stdout")
+	cmd.Flags().BoolVar(&versions, "versions", false, "include the previous versions of every banner")
+
+	return cmd
+}
+
+func newBannerImportCommand(o *options) *cobra.Command {
+	var opts client.ImportOptions
+
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Import banners from a JSON Lines or CSV file, - reads stdin",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var r io.Reader = os.Stdin
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+
+				if opts.Format == "" && strings.EqualFold(filepath.Ext(args[0]), ".csv") {
+					opts.Format = "csv"
+				}
+			}
+			if opts.Format == "" {
+				opts.Format = "jsonl"
+			}
+
+			api, err := o.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			report, err := api.ImportBanners(cmd.Context(), r, opts)
+			if err != nil {
+				return err
+			}
+
+			t := &table{header: []string{"LINE", "ERROR"}}
+			for _, e := range report.Errors {
+				t.add(e.Line, e.Error)
+			}
+			if err = o.render(report, t); err != nil {
+				return err
+			}
+
+			if report.Failed > 0 {
+				return fmt.Errorf("%d of %d lines failed, %d banners committed", report.Failed, report.Total, report.Committed)
+			}
+			if !report.DryRun {
+				fmt.Fprintf(o.out, "%d banners imported\n", report.Committed)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.Format, "format", "", "jsonl or csv, guessed from the file extension when empty")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "check every line without committing")
+	cmd.Flags().BoolVar(&opts.Chunked, "chunked", false, "commit valid lines chunk by chunk instead of all or nothing")
+	cmd.Flags().IntVar(&opts.ChunkSize, "chunk-size", 0, "lines per chunk, the server default when 0")
+
+	return cmd
+}
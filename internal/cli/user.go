@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"banner-service/internal/models"
+)
+
+// userRow is a user as list prints it, without the password field of models.User.
+type userRow struct {
+	UserID  int    `json:"user_id"`
+	Login   string `json:"login"`
+	IsAdmin bool   `json:"is_admin"`
+	TagID   int    `json:"tag_id"`
+}
+
+func newUserCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{Use: "user", Short: "Manage users"}
+	cmd.AddCommand(
+		newUserListCommand(o),
+		newUserCreateCommand(o),
+		newUserUpdateCommand(o),
+		newUserDeleteCommand(o),
+	)
+	return cmd
+}
+
+func newUserListCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List users from the database",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			repo, err := o.repository(cmd.Context())
+			if err != nil {
+				return err
+			}
+			users, err := repo.ListUsers(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			rows := make([]userRow, 0, len(users))
+			t := &table{header: []string{"ID", "LOGIN", "ADMIN", "TAG"}}
+			for _, u := range users {
+				rows = append(rows, userRow{UserID: u.UserID, Login: u.Login, IsAdmin: u.IsAdmin, TagID: u.TagID})
+				t.add(u.UserID, u.Login, u.IsAdmin, u.TagID)
+			}
+			return o.render(rows, t)
+		},
+	}
+}
+
+func newUserCreateCommand(o *options) *cobra.Command {
+	var u models.User
+
+	cmd := &cobra.Command{
+		Use:   "create LOGIN",
+		Short: "Sign up a user through the API",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			u.Login = args[0]
+
+			api, err := o.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if err = api.SignUp(cmd.Context(), u); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(o.out, "user %s created\n", u.Login)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&u.Password, "password", "", "password of the new user")
+	cmd.Flags().IntVar(&u.TagID, "tag", 0, "tag of the new user")
+	_ = cmd.MarkFlagRequired("password")
+
+	return cmd
+}
+
+func newUserUpdateCommand(o *options) *cobra.Command {
+	var (
+		isAdmin bool
+		tagID   int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "update LOGIN",
+		Short: "Change the role and tag of a user in the database",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repo, err := o.repository(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if err = repo.UpdateUser(cmd.Context(), args[0], isAdmin, tagID); err != nil {
+				return fmt.Errorf("user %s: %w", args[0], err)
+			}
+
+			fmt.Fprintf(o.out, "user %s updated\n", args[0])
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&isAdmin, "admin", false, "whether the user is an admin")
+	cmd.Flags().IntVar(&tagID, "tag", 0, "tag of the user, 0 for none")
+
+	return cmd
+}
+
+func newUserDeleteCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete LOGIN",
+		Short: "Delete a user from the database",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repo, err := o.repository(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if err = repo.DeleteUser(cmd.Context(), args[0]); err != nil {
+				return fmt.Errorf("user %s: %w", args[0], err)
+			}
+
+			fmt.Fprintf(o.out, "user %s deleted\n", args[0])
+			return nil
+		},
+	}
+}
@@ -52,3 +52,124 @@ type Versions struct {
 	CurrentVersion BannerVersion   `json:"current_version"`
 	OldVersions    []BannerVersion `json:"old_versions"`
 }
+
+// BannerRecord is a banner as exported and imported in bulk.
+type BannerRecord struct {
+	Banner
+	Version  int             `json:"version"`
+	Versions []BannerVersion `json:"versions,omitempty"`
+}
+
+type ImportLine struct {
+	Line   int
+	Record BannerRecord
+	// Err is set when the line could not be parsed.
+	Err error
+}
+
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type ImportReport struct {
+	DryRun    bool          `json:"dry_run"`
+	Atomic    bool          `json:"atomic"`
+	Total     int           `json:"total"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Committed int           `json:"committed"`
+	Errors    []ImportError `json:"errors"`
+}
+
+type ImportOptions struct {
+	// Atomic imports every line in one transaction that is committed only if all lines succeed,
+	// otherwise every chunk of ChunkSize lines is committed with its failed lines skipped.
+	Atomic    bool
+	DryRun    bool
+	ChunkSize int
+}
+
+// BatchResult is the outcome of one item of a batch, Index is its position in the request.
+type BatchResult struct {
+	Index    int    `json:"index"`
+	BannerID int    `json:"banner_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type BatchReport struct {
+	Atomic  bool          `json:"atomic"`
+	Created int           `json:"created"`
+	Failed  int           `json:"failed"`
+	Results []BatchResult `json:"results"`
+}
+
+// BannerFilter selects and orders banners for listing, zero values are not applied. After continues
+// a listing from the last banner of the previous page, it must have been made with the same SortBy and Desc.
+type BannerFilter struct {
+	TagID       int
+	FeatureID   int
+	IsActive    *bool
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	UpdatedFrom time.Time
+	UpdatedTo   time.Time
+	SortBy      string
+	Desc        bool
+	Content     []ContentFilter
+	Limit       int
+	Offset      int
+	After       *BannerCursor
+}
+
+// ContentFilter matches a banner whose content has a value at Path, equal to Value when it is set.
+// A value that is valid JSON, like 5 or true, also matches the typed JSON value.
+type ContentFilter struct {
+	Path  []string
+	Value *string
+}
+
+// BannerCursor is the position of a banner in a listing: its id and the value of the sort column.
+type BannerCursor struct {
+	SortBy string    `json:"s"`
+	Desc   bool      `json:"d,omitempty"`
+	ID     int       `json:"id"`
+	Value  time.Time `json:"v,omitempty"`
+}
+
+type BannerPage struct {
+	Banners []Banner
+	// Total counts every banner that matches the filter, regardless of Limit, Offset and After.
+	Total int
+	// Next is nil on the last page.
+	Next *BannerCursor
+}
+
+type BannerSearch struct {
+	// Query is in the web search syntax: quoted phrases, OR and -word.
+	Query     string
+	TagID     int
+	FeatureID int
+	Limit     int
+}
+
+type BannerSearchResult struct {
+	Banner
+	Rank float64 `json:"rank"`
+	// Highlights has an HTML escaped snippet with the matches in <mark> for every content key that matched.
+	Highlights map[string]string `json:"highlights"`
+}
+
+// BannerSnapshot is the content of every active banner by tag and feature, as an edge node serves it.
+// A snapshot with a greater Version replaces one with a smaller.
+type BannerSnapshot struct {
+	Version     int64            `json:"version"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Banners     []SnapshotBanner `json:"banners"`
+}
+
+type SnapshotBanner struct {
+	TagID     int             `json:"tag_id"`
+	FeatureID int             `json:"feature_id"`
+	Content   json.RawMessage `json:"content"`
+}
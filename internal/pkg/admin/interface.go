@@ -0,0 +1,20 @@
+package admin
+
+import (
+	"context"
+
+	"banner-service/internal/models"
+)
+
+// Repository manages the reference data that the HTTP API does not expose.
+type Repository interface {
+	ListTags(context.Context) ([]int, error)
+	CreateTag(context.Context, int) error
+	DeleteTag(context.Context, int) error
+	ListFeatures(context.Context) ([]int, error)
+	CreateFeature(context.Context, int) error
+	DeleteFeature(context.Context, int) error
+	ListUsers(context.Context) ([]models.User, error)
+	UpdateUser(ctx context.Context, login string, isAdmin bool, tagID int) error
+	DeleteUser(ctx context.Context, login string) error
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"banner-service/internal/models"
+)
+
+const (
+	listTags      = `SELECT tag_id FROM tag ORDER BY tag_id;`
+	createTag     = `INSERT INTO tag (tag_id) VALUES ($1);`
+	deleteTag     = `DELETE FROM tag WHERE tag_id=$1;`
+	listFeatures  = `SELECT feature_id FROM feature ORDER BY feature_id;`
+	createFeature = `INSERT INTO feature (feature_id) VALUES ($1);`
+	deleteFeature = `DELETE FROM feature WHERE feature_id=$1;`
+	listUsers     = `SELECT user_id, login, is_admin, COALESCE(tag_id, 0) FROM "user" ORDER BY user_id;`
+	updateUser    = `UPDATE "user" SET is_admin=$2, tag_id=NULLIF($3, 0) WHERE login=$1;`
+	deleteUser    = `DELETE FROM "user" WHERE login=$1;`
+
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrUnknownTag    = errors.New("tag does not exist")
+)
+
+type AdminRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAdminRepository(db *pgxpool.Pool) *AdminRepository {
+	return &AdminRepository{db: db}
+}
+
+func (ar *AdminRepository) ListTags(ctx context.Context) ([]int, error) {
+	return ar.listIDs(ctx, listTags)
+}
+
+func (ar *AdminRepository) CreateTag(ctx context.Context, id int) error {
+	return ar.exec(ctx, createTag, id)
+}
+
+func (ar *AdminRepository) DeleteTag(ctx context.Context, id int) error {
+	return ar.exec(ctx, deleteTag, id)
+}
+
+func (ar *AdminRepository) ListFeatures(ctx context.Context) ([]int, error) {
+	return ar.listIDs(ctx, listFeatures)
+}
+
+func (ar *AdminRepository) CreateFeature(ctx context.Context, id int) error {
+	return ar.exec(ctx, createFeature, id)
+}
+
+func (ar *AdminRepository) DeleteFeature(ctx context.Context, id int) error {
+	return ar.exec(ctx, deleteFeature, id)
+}
+
+func (ar *AdminRepository) ListUsers(ctx context.Context) ([]models.User, error) {
+	rows, err := ar.db.Query(ctx, listUsers)
+	if err != nil {
+		return nil, fmt.Errorf("error happened in db.Query: %w", err)
+	}
+
+	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.User, error) {
+		var u models.User
+		err := row.Scan(&u.UserID, &u.Login, &u.IsAdmin, &u.TagID)
+		return u, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error happened in rows.Scan: %w", err)
+	}
+
+	return users, nil
+}
+
+func (ar *AdminRepository) UpdateUser(ctx context.Context, login string, isAdmin bool, tagID int) error {
+	return ar.exec(ctx, updateUser, login, isAdmin, tagID)
+}
+
+func (ar *AdminRepository) DeleteUser(ctx context.Context, login string) error {
+	return ar.exec(ctx, deleteUser, login)
+}
+
+func (ar *AdminRepository) listIDs(ctx context.Context, query string) ([]int, error) {
+	rows, err := ar.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error happened in db.Query: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("error happened in rows.Scan: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (ar *AdminRepository) exec(ctx context.Context, query string, args ...interface{}) error {
+	tag, err := ar.db.Exec(ctx, query, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case uniqueViolation:
+				return ErrAlreadyExists
+			case foreignKeyViolation:
+				return ErrUnknownTag
+			}
+		}
+		return fmt.Errorf("error happened in db.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
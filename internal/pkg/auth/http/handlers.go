@@ -2,13 +2,20 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/sirupsen/logrus"
 
 	"banner-service/internal/models"
 	"banner-service/internal/pkg/auth"
+	authService "banner-service/internal/pkg/auth/sevice"
+	"banner-service/internal/pkg/logging"
+	"banner-service/internal/pkg/ratelimit"
+	"banner-service/internal/utils/clientip"
 	"banner-service/internal/utils/jwter"
 	"banner-service/internal/utils/responser"
 )
@@ -23,6 +30,10 @@ func NewAuthHandler(s auth.AuthService, logger *logrus.Logger, tokenManager *jwt
 	return &AuthHandler{s, logger, tokenManager}
 }
 
+func (ah *AuthHandler) log(r *http.Request) *logrus.Entry {
+	return logging.Entry(r.Context(), ah.logger)
+}
+
 func (ah *AuthHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 
@@ -39,9 +50,20 @@ func (ah *AuthHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = ah.service.SignIn(r.Context(), u)
+	err = ah.service.SignIn(r.Context(), u, clientip.FromRequest(r))
 	if err != nil {
-		responser.WriteStatus(w, http.StatusInternalServerError)
+		var limitErr *ratelimit.LimitError
+		switch {
+		case errors.As(err, &limitErr):
+			ah.log(r).Warn("sign in rejected for ", u.Login, ": ", err)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limitErr.RetryAfter.Seconds()))))
+			responser.WriteError(w, http.StatusTooManyRequests, errors.New(limitErr.Reason))
+		case errors.Is(err, authService.ErrInvalidCredentials):
+			responser.WriteStatus(w, http.StatusUnauthorized)
+		default:
+			ah.log(r).Error("failed to sign in ", err)
+			responser.WriteStatus(w, http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -73,6 +95,10 @@ func (ah *AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 
 	u.UserID, err = ah.service.SignUp(r.Context(), u)
 	if err != nil {
+		if errors.Is(err, authService.ErrReservedLogin) {
+			responser.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
 		responser.WriteStatus(w, http.StatusInternalServerError)
 		return
 	}
@@ -86,3 +112,15 @@ func (ah *AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 	http.SetCookie(w, &http.Cookie{Name: "AccessToken", Value: token})
 	responser.WriteStatus(w, http.StatusOK)
 }
+
+func (ah *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	jwks, err := ah.tokenManager.JWKS()
+	if err != nil {
+		ah.log(r).Error("failed to build jwks ", err)
+		responser.WriteStatus(w, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	responser.WriteJSON(w, http.StatusOK, jwks)
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/pkg/auth"
+	"banner-service/internal/pkg/auth/oidc"
+	"banner-service/internal/pkg/logging"
+	"banner-service/internal/utils/jwter"
+	"banner-service/internal/utils/responser"
+)
+
+const (
+	oidcStateCookie = "OIDCState"
+	oidcStateTTL    = 10 * time.Minute
+)
+
+type OIDCHandler struct {
+	service      auth.AuthService
+	provider     *oidc.Provider
+	logger       *logrus.Logger
+	tokenManager *jwter.Manager
+}
+
+func NewOIDCHandler(s auth.AuthService, provider *oidc.Provider, logger *logrus.Logger,
+	tokenManager *jwter.Manager) *OIDCHandler {
+	return &OIDCHandler{s, provider, logger, tokenManager}
+}
+
+func (oh *OIDCHandler) log(r *http.Request) *logrus.Entry {
+	return logging.Entry(r.Context(), oh.logger)
+}
+
+func (oh *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	values := make([]string, 3)
+	for i := range values {
+		v, err := oidc.RandomString()
+		if err != nil {
+			oh.log(r).Error("failed to generate oidc state ", err)
+			responser.WriteStatus(w, http.StatusInternalServerError)
+			return
+		}
+		values[i] = v
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    strings.Join(values, "."),
+		Path:     "/api/oidc",
+		MaxAge:   int(oidcStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, oh.provider.AuthCodeURL(state, nonce, verifier), http.StatusFound)
+}
+
+func (oh *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	stateCookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		oh.log(r).Error("oidc state cookie not found ", err)
+		responser.WriteError(w, http.StatusBadRequest, errors.New("missing oidc state"))
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/api/oidc", MaxAge: -1})
+
+	values := strings.Split(stateCookie.Value, ".")
+	if len(values) != 3 || values[0] != r.URL.Query().Get("state") {
+		oh.log(r).Error("oidc state mismatch")
+		responser.WriteError(w, http.StatusBadRequest, errors.New("invalid oidc state"))
+		return
+	}
+	nonce, verifier := values[1], values[2]
+
+	if errParam := r.URL.Query().Get("error"); errParam != "" {
+		oh.log(r).Error("oidc provider returned error ", errParam)
+		responser.WriteError(w, http.StatusUnauthorized, errors.New(errParam))
+		return
+	}
+
+	identity, err := oh.provider.Exchange(r.Context(), r.URL.Query().Get("code"), verifier, nonce)
+	if err != nil {
+		oh.log(r).Error("failed to exchange oidc code ", err)
+		responser.WriteStatus(w, http.StatusUnauthorized)
+		return
+	}
+
+	u, err := oh.provider.User(identity)
+	if err != nil {
+		oh.log(r).Error("oidc identity rejected ", identity.Subject, " ", err)
+		responser.WriteStatus(w, http.StatusForbidden)
+		return
+	}
+
+	if err = oh.service.SignInExternal(r.Context(), &u); err != nil {
+		oh.log(r).Error("failed to sign in external user ", err)
+		responser.WriteStatus(w, http.StatusInternalServerError)
+		return
+	}
+
+	token, err := oh.tokenManager.GenerateJWT(&u)
+	if err != nil {
+		responser.WriteStatus(w, http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: "AccessToken", Value: token, Path: "/api"})
+	responser.WriteStatus(w, http.StatusOK)
+}
@@ -8,9 +8,12 @@ import (
 type Repository interface {
 	CreateUser(context.Context, *models.User) (int, error)
 	ReadUserByLogin(context.Context, string) (models.User, error)
+	CreateSignInFailure(ctx context.Context, login, ip string) error
+	UpsertExternalUser(context.Context, *models.User) (int, error)
 }
 
 type AuthService interface {
-	SignIn(context.Context, *models.User) error
+	SignIn(ctx context.Context, user *models.User, ip string) error
 	SignUp(context.Context, *models.User) (int, error)
+	SignInExternal(context.Context, *models.User) error
 }
@@ -0,0 +1,160 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"banner-service/internal/models"
+)
+
+var (
+	ErrNonceMismatch = errors.New("id token nonce mismatch")
+	ErrNoIDToken     = errors.New("token response has no id_token")
+	ErrNoAccess      = errors.New("identity has no admin or tag group")
+)
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	GroupsClaim  string
+	AdminGroups  []string
+	TagGroups    map[string]int
+}
+
+type Identity struct {
+	Subject string
+	Name    string
+	Groups  []string
+}
+
+type Provider struct {
+	oauth       oauth2.Config
+	verifier    *oidc.IDTokenVerifier
+	groupsClaim string
+	adminGroups map[string]struct{}
+	tagGroups   map[string]int
+}
+
+func NewProvider(ctx context.Context, cfg Config) (*Provider, error) {
+	p, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("error happened in oidc.NewProvider: %w", err)
+	}
+
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "profile"}
+	}
+
+	groupsClaim := cfg.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+
+	adminGroups := make(map[string]struct{}, len(cfg.AdminGroups))
+	for _, g := range cfg.AdminGroups {
+		adminGroups[g] = struct{}{}
+	}
+
+	return &Provider{
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     p.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier:    p.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		groupsClaim: groupsClaim,
+		adminGroups: adminGroups,
+		tagGroups:   cfg.TagGroups,
+	}, nil
+}
+
+func (p *Provider) AuthCodeURL(state, nonce, verifier string) string {
+	return p.oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+}
+
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (Identity, error) {
+	token, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return Identity{}, fmt.Errorf("error happened in oauth.Exchange: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return Identity{}, ErrNoIDToken
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return Identity{}, fmt.Errorf("error happened in verifier.Verify: %w", err)
+	}
+
+	if idToken.Nonce != nonce {
+		return Identity{}, ErrNonceMismatch
+	}
+
+	var claims map[string]interface{}
+	if err = idToken.Claims(&claims); err != nil {
+		return Identity{}, err
+	}
+
+	identity := Identity{Subject: idToken.Subject}
+	if name, ok := claims["preferred_username"].(string); ok {
+		identity.Name = name
+	}
+
+	groups, _ := claims[p.groupsClaim].([]interface{})
+	for _, g := range groups {
+		if group, ok := g.(string); ok {
+			identity.Groups = append(identity.Groups, group)
+		}
+	}
+
+	return identity, nil
+}
+
+// User maps provider groups onto the local admin flag and tag, picking the lowest tag on several matches.
+func (p *Provider) User(identity Identity) (models.User, error) {
+	u := models.User{Login: "oidc:" + identity.Subject}
+
+	tags := make([]int, 0)
+	for _, group := range identity.Groups {
+		if _, ok := p.adminGroups[group]; ok {
+			u.IsAdmin = true
+		}
+		if tagID, ok := p.tagGroups[group]; ok {
+			tags = append(tags, tagID)
+		}
+	}
+
+	if len(tags) > 0 {
+		sort.Ints(tags)
+		u.TagID = tags[0]
+	}
+
+	if !u.IsAdmin && u.TagID == 0 {
+		return models.User{}, ErrNoAccess
+	}
+
+	return u, nil
+}
+
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
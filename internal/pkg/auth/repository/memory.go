@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/memstore"
+)
+
+// The lengths of "user".login and "user".password.
+const (
+	maxLoginLength    = 255
+	maxPasswordLength = 32
+)
+
+// MemoryAuthRepository keeps the users in a memstore.Store with the semantics of AuthRepository.
+type MemoryAuthRepository struct {
+	store *memstore.Store
+}
+
+func NewMemoryAuthRepository(store *memstore.Store) *MemoryAuthRepository {
+	return &MemoryAuthRepository{store: store}
+}
+
+// CreateUser needs an existing tag, the user row is written with the tag as it is.
+func (mr *MemoryAuthRepository) CreateUser(_ context.Context, user *models.User) (int, error) {
+	if utf8.RuneCountInString(user.Login) > maxLoginLength ||
+		utf8.RuneCountInString(user.Password) > maxPasswordLength {
+		return 0, fmt.Errorf("login must be at most %d and password %d characters", maxLoginLength, maxPasswordLength)
+	}
+
+	var id int
+	err := mr.store.Write(func(tx *memstore.Tx) error {
+		if !tx.Tag(user.TagID) {
+			return fmt.Errorf("%w: tag %d does not exist", memstore.ErrForeignKeyViolation, user.TagID)
+		}
+		id = tx.NextUserID()
+		return tx.PutUser(models.User{UserID: id, Login: user.Login, Password: user.Password, TagID: user.TagID})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (mr *MemoryAuthRepository) ReadUserByLogin(_ context.Context, login string) (models.User, error) {
+	var u models.User
+	err := mr.store.Read(func(tx *memstore.Tx) error {
+		var ok bool
+		if u, ok = tx.User(login); !ok {
+			return ErrUserNotFound
+		}
+		return nil
+	})
+	return u, err
+}
+
+func (mr *MemoryAuthRepository) CreateSignInFailure(_ context.Context, login, ip string) error {
+	return mr.store.Write(func(tx *memstore.Tx) error {
+		return tx.AddSignInFailure(login, ip)
+	})
+}
+
+func (mr *MemoryAuthRepository) UpsertExternalUser(_ context.Context, user *models.User) (int, error) {
+	if utf8.RuneCountInString(user.Login) > maxLoginLength {
+		return 0, fmt.Errorf("login must be at most %d characters", maxLoginLength)
+	}
+
+	var id int
+	err := mr.store.Write(func(tx *memstore.Tx) error {
+		u, ok := tx.User(user.Login)
+		if ok && u.Password != "" {
+			return ErrLoginTaken
+		}
+		if !ok {
+			u = models.User{UserID: tx.NextUserID(), Login: user.Login}
+		}
+		u.IsAdmin, u.TagID = user.IsAdmin, user.TagID
+		id = u.UserID
+		return tx.PutUser(u)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"banner-service/internal/models"
@@ -11,7 +13,17 @@ import (
 
 const (
 	createUser     = `INSERT INTO "user" (login, password, tag_id) VALUES ($1, $2, $3) RETURNING user_id;`
-	getUserByLogin = `SELECT user_id, password, is_admin, tag_id FROM "user" WHERE login=$1`
+	getUserByLogin = `SELECT user_id, password, is_admin, COALESCE(tag_id, 0) FROM "user" WHERE login=$1`
+	createFailure  = `INSERT INTO sign_in_failure (login, ip) VALUES ($1, $2);`
+	upsertExternal = `INSERT INTO "user" (login, password, is_admin, tag_id) VALUES ($1, '', $2, NULLIF($3, 0))
+                      ON CONFLICT (login) DO UPDATE SET is_admin = EXCLUDED.is_admin, tag_id = EXCLUDED.tag_id
+                      WHERE "user".password = '' RETURNING user_id;`
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	// ErrLoginTaken means that an external account has the login of a user who signs in with a password.
+	ErrLoginTaken = errors.New("login belongs to a local user")
 )
 
 type AuthRepository struct {
@@ -45,6 +57,9 @@ func (ar *AuthRepository) ReadUserByLogin(ctx context.Context, login string) (mo
 	err := ar.db.QueryRow(ctx, getUserByLogin, login).Scan(&u.UserID, &u.Password, &u.IsAdmin, &u.TagID)
 
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, ErrUserNotFound
+		}
 		err = fmt.Errorf("error happened in scan.Scan: %w", err)
 
 		return models.User{}, err
@@ -52,3 +67,27 @@ func (ar *AuthRepository) ReadUserByLogin(ctx context.Context, login string) (mo
 
 	return u, nil
 }
+
+func (ar *AuthRepository) CreateSignInFailure(ctx context.Context, login, ip string) error {
+	if _, err := ar.db.Exec(ctx, createFailure, login, ip); err != nil {
+		return fmt.Errorf("error happened in db.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (ar *AuthRepository) UpsertExternalUser(ctx context.Context, user *models.User) (int, error) {
+	var id int
+	err := ar.db.QueryRow(ctx, upsertExternal, user.Login, user.IsAdmin, user.TagID).Scan(&id)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrLoginTaken
+	}
+	if err != nil {
+		err = fmt.Errorf("error happened in scan.Scan: %w", err)
+
+		return 0, err
+	}
+
+	return id, nil
+}
@@ -3,37 +3,92 @@ package sevice
 import (
 	"banner-service/internal/models"
 	"banner-service/internal/pkg/auth"
+	"banner-service/internal/pkg/auth/repository"
+	"banner-service/internal/pkg/logging"
+	"banner-service/internal/pkg/ratelimit"
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid login or password")
+	ErrReservedLogin      = errors.New("login is reserved for external accounts")
 )
 
+const externalLoginPrefix = "oidc:"
+
 type AuthService struct {
-	repo auth.Repository
+	repo   auth.Repository
+	guard  *ratelimit.SignInGuard
+	logger *logrus.Logger
 }
 
-func NewAuthService(repo auth.Repository) *AuthService {
+func NewAuthService(repo auth.Repository, guard *ratelimit.SignInGuard, logger *logrus.Logger) *AuthService {
 	return &AuthService{
-		repo: repo,
+		repo:   repo,
+		guard:  guard,
+		logger: logger,
 	}
 }
 
-func (as *AuthService) SignIn(ctx context.Context, user *models.User) error {
+func (as *AuthService) SignIn(ctx context.Context, user *models.User, ip string) error {
+	if as.guard != nil {
+		if err := as.guard.Allow(ctx, user.Login, ip); err != nil {
+			return err
+		}
+	}
+
 	u, err := as.repo.ReadUserByLogin(ctx, user.Login)
-	if err != nil {
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
 		return err
 	}
 
-	if u.Password == user.Password {
+	if err == nil && u.Password != "" && u.Password == user.Password {
 		user.UserID = u.UserID
 		user.IsAdmin = u.IsAdmin
 		user.TagID = u.TagID
+
+		if as.guard != nil {
+			if err = as.guard.Succeed(ctx, user.Login); err != nil {
+				logging.Entry(ctx, as.logger).Error("failed to reset sign in failures ", err)
+			}
+		}
 		return nil
 	}
 
-	return errors.New("forbidden")
+	// The lockout counts the failure even when the log of failures is down, or it would never lock.
+	if err = as.repo.CreateSignInFailure(ctx, user.Login, ip); err != nil {
+		err = fmt.Errorf("failed to log sign in failure: %w", err)
+	}
+	if as.guard != nil {
+		err = errors.Join(err, as.guard.Fail(ctx, user.Login))
+	}
+	if err != nil {
+		return err
+	}
+
+	return ErrInvalidCredentials
 }
 
 func (as *AuthService) SignUp(ctx context.Context, user *models.User) (int, error) {
+	if strings.HasPrefix(user.Login, externalLoginPrefix) {
+		return 0, ErrReservedLogin
+	}
+
 	id, err := as.repo.CreateUser(ctx, user)
 	return id, err
 }
+
+func (as *AuthService) SignInExternal(ctx context.Context, user *models.User) error {
+	id, err := as.repo.UpsertExternalUser(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	user.UserID = id
+	return nil
+}
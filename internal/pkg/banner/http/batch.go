@@ -0,0 +1,77 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/banner/repository"
+	"banner-service/internal/pkg/tracing"
+	"banner-service/internal/utils/responser"
+)
+
+const (
+	maxBatchSize = 1000
+	maxBatchBody = 16 << 20
+)
+
+func (h *BannerHandler) AddBanners(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.Start(r.Context(), "BannerHandler.AddBanners")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	h.log(r).Debug("add banners handler")
+
+	var atomic bool
+	switch r.URL.Query().Get("mode") {
+	case "", "atomic":
+		atomic = true
+	case "best_effort":
+	default:
+		responser.WriteError(w, http.StatusBadRequest, errors.New("mode must be atomic or best_effort"))
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxBatchBody)
+	defer body.Close()
+
+	var banners []models.BannerPayload
+	if err := json.NewDecoder(body).Decode(&banners); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			responser.WriteError(w, http.StatusRequestEntityTooLarge, errors.New("body request is too large"))
+			return
+		}
+		h.log(r).Warn("error in unmarshall: ", err)
+		responser.WriteError(w, http.StatusBadRequest, errors.New("body request must be a json array of banners"))
+		return
+	}
+	if len(banners) == 0 || len(banners) > maxBatchSize {
+		responser.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("batch must have from 1 to %d banners", maxBatchSize))
+		return
+	}
+
+	report, err := h.service.AddBanners(r.Context(), banners, atomic)
+	if errors.Is(err, repository.ErrBatchConflict) {
+		h.log(r).Warn(err)
+		responser.WriteError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		h.log(r).Error("failed to create banners: ", err)
+		responser.WriteError(w, http.StatusInternalServerError, errors.New("failed to create banners"))
+		return
+	}
+
+	h.log(r).Info("created ", report.Created, " of ", len(banners), " banners")
+
+	status := http.StatusCreated
+	if report.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	reportJSON, _ := json.Marshal(report)
+	responser.WriteJSON(w, status, reportJSON)
+}
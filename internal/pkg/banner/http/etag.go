@@ -0,0 +1,60 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"banner-service/internal/utils/responser"
+)
+
+// SetCacheTTL sets how long clients may reuse a banner, it should be the time the server caches it for.
+func (h *UserBannerHandler) SetCacheTTL(ttl time.Duration) {
+	h.cacheTTL.Store(int64(ttl))
+}
+
+// etag is a strong tag of the body, the same content has the same tag whether it came from the cache or
+// the repository.
+func etag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified compares If-None-Match weakly, as RFC 9110 asks for GET.
+func notModified(r *http.Request, tag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCacheable writes body with its ETag, or 304 Not Modified when the client already has it. A client
+// that asked for the latest revision must revalidate every time.
+func (h *UserBannerHandler) writeCacheable(w http.ResponseWriter, r *http.Request, body []byte, latest bool) {
+	tag := etag(body)
+	w.Header().Set("ETag", tag)
+
+	ttl := time.Duration(h.cacheTTL.Load())
+	if latest || ttl < time.Second {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	} else {
+		w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(int(ttl.Seconds())))
+	}
+
+	if notModified(r, tag) {
+		responser.WriteStatus(w, http.StatusNotModified)
+		return
+	}
+	responser.WriteJSON(w, http.StatusOK, body)
+}
@@ -1,11 +1,13 @@
 package http
 
 import (
+	"crypto/ed25519"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"strconv"
+	"sync/atomic"
 
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -13,31 +15,53 @@ import (
 	"banner-service/internal/models"
 	"banner-service/internal/pkg/banner"
 	"banner-service/internal/pkg/banner/repository"
+	"banner-service/internal/pkg/logging"
+	"banner-service/internal/pkg/tracing"
 	"banner-service/internal/utils/responser"
 )
 
+// UserBannerHandler serves GET /api/user_banner, the only route of an edge node.
+type UserBannerHandler struct {
+	reader   banner.BannerReader
+	logger   *logrus.Logger
+	cacheTTL atomic.Int64
+}
+
+func NewUserBannerHandler(reader banner.BannerReader, logger *logrus.Logger) *UserBannerHandler {
+	return &UserBannerHandler{reader: reader, logger: logger}
+}
+
 type BannerHandler struct {
-	service banner.BannerService
-	logger  *logrus.Logger
+	*UserBannerHandler
+	service     banner.BannerService
+	snapshotKey ed25519.PrivateKey
 }
 
 func NewBannerHandler(s banner.BannerService, logger *logrus.Logger) *BannerHandler {
-	return &BannerHandler{s, logger}
+	return &BannerHandler{UserBannerHandler: NewUserBannerHandler(s, logger), service: s}
+}
+
+func (h *UserBannerHandler) log(r *http.Request) *logrus.Entry {
+	return logging.Entry(r.Context(), h.logger)
 }
 
-func (h *BannerHandler) GetBanner(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("get banner handler")
+func (h *UserBannerHandler) GetBanner(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.Start(r.Context(), "BannerHandler.GetBanner")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	h.log(r).Debug("get banner handler")
 
 	tagIDStr := r.URL.Query().Get("tag_id")
 	tagID, err := strconv.Atoi(tagIDStr)
 	if err != nil {
-		h.logger.Error("incorrect tag id ", err)
+		h.log(r).Error("incorrect tag id ", err)
 		responser.WriteError(w, http.StatusBadRequest, errors.New("incorrect tag id"))
 		return
 	}
 
 	if !r.Context().Value("is_admin").(bool) && tagID != r.Context().Value("tag_id") {
-		h.logger.Error("this tag id forbidden")
+		h.log(r).Error("this tag id forbidden")
 		responser.WriteStatus(w, http.StatusForbidden)
 		return
 	}
@@ -45,7 +69,7 @@ func (h *BannerHandler) GetBanner(w http.ResponseWriter, r *http.Request) {
 	featureIDStr := r.URL.Query().Get("feature_id")
 	featureID, err := strconv.Atoi(featureIDStr)
 	if err != nil {
-		h.logger.Error("incorrect feature id ", err)
+		h.log(r).Error("incorrect feature id ", err)
 		responser.WriteError(w, http.StatusBadRequest, errors.New("incorrect feature id"))
 		return
 	}
@@ -55,16 +79,16 @@ func (h *BannerHandler) GetBanner(w http.ResponseWriter, r *http.Request) {
 	if useLastRevisionStr != "" {
 		useLastRevision, err = strconv.ParseBool(useLastRevisionStr)
 		if err != nil {
-			h.logger.Error("incorrect use last revision ", err)
+			h.log(r).Error("incorrect use last revision ", err)
 			responser.WriteError(w, http.StatusBadRequest, errors.New("incorrect use last revision"))
 			return
 		}
 	}
 
-	banner, err := h.service.GetBanner(r.Context(), tagID, featureID, useLastRevision,
+	banner, err := h.reader.GetBanner(r.Context(), tagID, featureID, useLastRevision,
 		r.Context().Value("is_admin").(bool))
 	if err != nil {
-		h.logger.Error("failed to get banner ", err)
+		h.log(r).Error("failed to get banner ", err)
 		if errors.Is(err, repository.ErrBannerNotFound) {
 			responser.WriteStatus(w, http.StatusNotFound)
 			return
@@ -73,23 +97,27 @@ func (h *BannerHandler) GetBanner(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responser.WriteJSON(w, http.StatusOK, banner)
+	h.writeCacheable(w, r, banner, useLastRevision)
 }
 
 func (h *BannerHandler) GetBannerVersions(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("get banner versions handler")
+	ctx, span := tracing.Start(r.Context(), "BannerHandler.GetBannerVersions")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	h.log(r).Debug("get banner versions handler")
 
 	vars := mux.Vars(r)
 	idStr, ok := vars["id"]
 	if !ok || idStr == "" {
-		h.logger.Error("id is empty")
+		h.log(r).Error("id is empty")
 		responser.WriteError(w, http.StatusBadRequest, errors.New("empty id in request"))
 		return
 	}
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		h.logger.Error("id is incorrect")
+		h.log(r).Error("id is incorrect")
 		responser.WriteError(w, http.StatusBadRequest, errors.New("incorrect id in  request"))
 		return
 	}
@@ -99,11 +127,13 @@ func (h *BannerHandler) GetBannerVersions(w http.ResponseWriter, r *http.Request
 	versions.CurrentVersion, err = h.service.GetCurrentBanner(r.Context(), id)
 
 	if err != nil {
-		h.logger.Error("failed to get banner ", err)
+		h.log(r).Error("failed to get banner ", err)
 		if errors.Is(err, repository.ErrBannerNotFound) {
 			responser.WriteStatus(w, http.StatusNotFound)
 			return
 		}
+		responser.WriteError(w, http.StatusInternalServerError, err)
+		return
 	}
 
 	versions.OldVersions, err = h.service.GetOldBanners(r.Context(), id)
@@ -114,82 +144,67 @@ func (h *BannerHandler) GetBannerVersions(w http.ResponseWriter, r *http.Request
 
 	versionsJSON, err := json.Marshal(versions)
 	if err != nil {
-		h.logger.Error("failed to get banners ", err)
+		h.log(r).Error("failed to get banners ", err)
 		responser.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	responser.WriteJSON(w, http.StatusOK, versionsJSON)
+	// The versions are not cached on the server, clients revalidate so that an edit shows up at once.
+	h.writeCacheable(w, r, versionsJSON, true)
 }
 
+// GetBannerList writes the page as an array, the total and the cursor of the next page go in headers.
 func (h *BannerHandler) GetBannerList(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("get banners handler")
+	ctx, span := tracing.Start(r.Context(), "BannerHandler.GetBannerList")
+	defer span.End()
+	r = r.WithContext(ctx)
 
-	var err error
+	h.log(r).Debug("get banners handler")
 
-	tagIDStr := r.URL.Query().Get("tag_id")
-	tagID := 0
-	if tagIDStr != "" {
-		tagID, err = strconv.Atoi(tagIDStr)
-		if err != nil {
-			h.logger.Error("incorrect tag id ", err, tagIDStr)
-			responser.WriteError(w, http.StatusBadRequest, errors.New("incorrect tag id"))
-			return
-		}
-	}
-
-	featureIDStr := r.URL.Query().Get("feature_id")
-	featureID := 0
-	if tagIDStr != "" {
-		featureID, err = strconv.Atoi(featureIDStr)
-		if err != nil {
-			h.logger.Error("incorrect feature id ", err)
-			responser.WriteError(w, http.StatusBadRequest, errors.New("incorrect feature id"))
-			return
-		}
-	}
-
-	limitStr := r.URL.Query().Get("limit")
-	limit := 0
-	if limitStr != "" {
-		limit, err = strconv.Atoi(limitStr)
-		if err != nil {
-			h.logger.Error("incorrect limit ", err)
-			responser.WriteError(w, http.StatusBadRequest, errors.New("incorrect limit"))
-			return
-		}
-	}
-
-	offsetStr := r.URL.Query().Get("offset")
-	offset := 0
-	if offsetStr != "" {
-		offset, err = strconv.Atoi(offsetStr)
-		if err != nil {
-			h.logger.Error("incorrect offset ", err)
-			responser.WriteError(w, http.StatusBadRequest, errors.New("incorrect offset"))
-			return
-		}
+	filter, err := parseBannerFilter(r.URL.Query())
+	if err != nil {
+		h.log(r).Error("incorrect banner filter ", err)
+		responser.WriteError(w, http.StatusBadRequest, err)
+		return
 	}
 
-	banners, err := h.service.GetFilterBanners(r.Context(), tagID, featureID, limit, offset)
+	page, err := h.service.GetFilterBanners(r.Context(), filter)
 	if err != nil {
-		h.logger.Error("failed to get banners ", err)
+		h.log(r).Error("failed to get banners ", err)
 		responser.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	bannersJSON, err := json.Marshal(banners)
+	bannersJSON, err := json.Marshal(page.Banners)
 	if err != nil {
-		h.logger.Error("failed to get banners ", err)
+		h.log(r).Error("failed to get banners ", err)
 		responser.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.Next != nil {
+		cursor := encodeCursor(page.Next)
+		next := *r.URL
+		q := next.Query()
+		q.Set("cursor", cursor)
+		q.Del("sort")
+		q.Del("order")
+		next.RawQuery = q.Encode()
+
+		w.Header().Set("X-Next-Cursor", cursor)
+		w.Header().Set("Link", "<"+next.RequestURI()+">; rel=\"next\"")
+	}
+
 	responser.WriteJSON(w, http.StatusOK, bannersJSON)
 }
 
 func (h *BannerHandler) AddBanner(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("add banner handler")
+	ctx, span := tracing.Start(r.Context(), "BannerHandler.AddBanner")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	h.log(r).Debug("add banner handler")
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -202,7 +217,7 @@ func (h *BannerHandler) AddBanner(w http.ResponseWriter, r *http.Request) {
 	err = json.Unmarshal(body, b)
 
 	if err != nil {
-		h.logger.Error("error in unmarshall")
+		h.log(r).Error("error in unmarshall")
 		responser.WriteError(w, http.StatusBadRequest, errors.New("invalid json in body request"))
 		return
 	}
@@ -210,7 +225,7 @@ func (h *BannerHandler) AddBanner(w http.ResponseWriter, r *http.Request) {
 	bannerID, err := h.service.AddBanner(r.Context(), b)
 	if err != nil {
 		responser.WriteError(w, http.StatusInternalServerError, err)
-		h.logger.Error(err)
+		h.log(r).Error(err)
 		return
 	}
 
@@ -222,19 +237,23 @@ func (h *BannerHandler) AddBanner(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *BannerHandler) UpdateBanner(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("update banner handler")
+	ctx, span := tracing.Start(r.Context(), "BannerHandler.UpdateBanner")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	h.log(r).Debug("update banner handler")
 
 	vars := mux.Vars(r)
 	idStr, ok := vars["id"]
 	if !ok || idStr == "" {
-		h.logger.Error("id is empty")
+		h.log(r).Error("id is empty")
 		responser.WriteError(w, http.StatusBadRequest, errors.New("empty id in request"))
 		return
 	}
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		h.logger.Error("id is incorrect ", err)
+		h.log(r).Error("id is incorrect ", err)
 		responser.WriteError(w, http.StatusBadRequest, errors.New("incorrect id in request"))
 		return
 	}
@@ -250,14 +269,14 @@ func (h *BannerHandler) UpdateBanner(w http.ResponseWriter, r *http.Request) {
 	err = json.Unmarshal(body, b)
 
 	if err != nil {
-		h.logger.Error("error in unmarshall")
+		h.log(r).Error("error in unmarshall")
 		responser.WriteError(w, http.StatusBadRequest, errors.New("invalid json in body request"))
 		return
 	}
 
 	err = h.service.UpdateBanner(r.Context(), id, b)
 	if err != nil {
-		h.logger.Error("failed to update banner ", err)
+		h.log(r).Error("failed to update banner ", err)
 		if errors.Is(err, repository.ErrBannerNotFound) {
 			responser.WriteStatus(w, http.StatusNotFound)
 			return
@@ -270,26 +289,30 @@ func (h *BannerHandler) UpdateBanner(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *BannerHandler) DeleteBanner(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("delete banner handler")
+	ctx, span := tracing.Start(r.Context(), "BannerHandler.DeleteBanner")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	h.log(r).Debug("delete banner handler")
 
 	vars := mux.Vars(r)
 	idStr, ok := vars["id"]
 	if !ok || idStr == "" {
-		h.logger.Error("id is empty")
+		h.log(r).Error("id is empty")
 		responser.WriteError(w, http.StatusBadRequest, errors.New("empty id in request"))
 		return
 	}
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		h.logger.Error("id is incorrect")
+		h.log(r).Error("id is incorrect")
 		responser.WriteError(w, http.StatusBadRequest, errors.New("incorrect id in  request"))
 		return
 	}
 
 	err = h.service.DeleteBanner(r.Context(), id)
 	if err != nil {
-		h.logger.Error("failed to delete banner ", err)
+		h.log(r).Error("failed to delete banner ", err)
 		if errors.Is(err, repository.ErrBannerNotFound) {
 			responser.WriteStatus(w, http.StatusNotFound)
 			return
@@ -302,19 +325,23 @@ func (h *BannerHandler) DeleteBanner(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *BannerHandler) ChangeVersionBanner(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("change version of banner handler")
+	ctx, span := tracing.Start(r.Context(), "BannerHandler.ChangeVersionBanner")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	h.log(r).Debug("change version of banner handler")
 
 	vars := mux.Vars(r)
 	idStr, ok := vars["id"]
 	if !ok || idStr == "" {
-		h.logger.Error("id is empty")
+		h.log(r).Error("id is empty")
 		responser.WriteError(w, http.StatusBadRequest, errors.New("empty id in request"))
 		return
 	}
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		h.logger.Error("id is incorrect")
+		h.log(r).Error("id is incorrect")
 		responser.WriteError(w, http.StatusBadRequest, errors.New("incorrect id in  request"))
 		return
 	}
@@ -332,14 +359,14 @@ func (h *BannerHandler) ChangeVersionBanner(w http.ResponseWriter, r *http.Reque
 	err = json.Unmarshal(body, &version)
 
 	if err != nil {
-		h.logger.Error("error in unmarshall")
+		h.log(r).Error("error in unmarshall")
 		responser.WriteError(w, http.StatusBadRequest, errors.New("invalid json in body request"))
 		return
 	}
 
 	err = h.service.ChangeVersionOfBanner(r.Context(), id, version.Version)
 	if err != nil {
-		h.logger.Error("failed to change version of banner ", err)
+		h.log(r).Error("failed to change version of banner ", err)
 		if errors.Is(err, repository.ErrBannerNotFound) {
 			responser.WriteStatus(w, http.StatusNotFound)
 			return
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"banner-service/internal/models"
+)
+
+const maxContentPath = 5
+
+var (
+	contentKey       = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	errInvalidCursor = errors.New("incorrect cursor")
+	errCursorSort    = errors.New("cursor was made for another sort or order")
+	errCursorOffset  = errors.New("offset and cursor cannot be combined")
+)
+
+// parseBannerFilter reads the filters of GET /api/banner. The sort and order of a cursor apply when the
+// request does not set them.
+func parseBannerFilter(q url.Values) (models.BannerFilter, error) {
+	var (
+		f   models.BannerFilter
+		err error
+	)
+
+	for name, dst := range map[string]*int{
+		"tag_id": &f.TagID, "feature_id": &f.FeatureID, "limit": &f.Limit, "offset": &f.Offset,
+	} {
+		if v := q.Get(name); v != "" {
+			if *dst, err = strconv.Atoi(v); err != nil || *dst < 0 {
+				return f, fmt.Errorf("incorrect %s", name)
+			}
+		}
+	}
+
+	if v := q.Get("is_active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, errors.New("incorrect is_active")
+		}
+		f.IsActive = &active
+	}
+
+	for name, dst := range map[string]*time.Time{
+		"created_from": &f.CreatedFrom, "created_to": &f.CreatedTo,
+		"updated_from": &f.UpdatedFrom, "updated_to": &f.UpdatedTo,
+	} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return f, fmt.Errorf("incorrect %s, expected RFC 3339 time", name)
+			}
+			*dst = t.UTC()
+		}
+	}
+
+	if f.Content, err = parseContentFilters(q); err != nil {
+		return f, err
+	}
+
+	f.SortBy = q.Get("sort")
+	switch f.SortBy {
+	case "", "banner_id", "created_at", "updated_at":
+	default:
+		return f, errors.New("sort must be banner_id, created_at or updated_at")
+	}
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		f.Desc = true
+	default:
+		return f, errors.New("order must be asc or desc")
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		if f.Offset != 0 {
+			return f, errCursorOffset
+		}
+		if f.After, err = decodeCursor(v); err != nil {
+			return f, err
+		}
+		if (f.SortBy != "" && f.SortBy != f.After.SortBy) || (q.Get("order") != "" && f.Desc != f.After.Desc) {
+			return f, errCursorSort
+		}
+		f.SortBy, f.Desc = f.After.SortBy, f.After.Desc
+	}
+	if f.SortBy == "" {
+		f.SortBy = "banner_id"
+	}
+
+	return f, nil
+}
+
+// parseContentFilters reads content.<path>=value and content_has=<path>, the path is dot separated keys.
+func parseContentFilters(q url.Values) ([]models.ContentFilter, error) {
+	var filters []models.ContentFilter
+
+	parsePath := func(path string) ([]string, error) {
+		keys := strings.Split(path, ".")
+		if len(keys) > maxContentPath {
+			return nil, fmt.Errorf("content path %s is deeper than %d keys", path, maxContentPath)
+		}
+		for _, k := range keys {
+			if !contentKey.MatchString(k) {
+				return nil, fmt.Errorf("content path %s must be keys of letters, digits, _ and -", path)
+			}
+		}
+		return keys, nil
+	}
+
+	for _, path := range q["content_has"] {
+		keys, err := parsePath(path)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, models.ContentFilter{Path: keys})
+	}
+
+	params := make([]string, 0)
+	for name := range q {
+		if strings.HasPrefix(name, "content.") {
+			params = append(params, name)
+		}
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		keys, err := parsePath(strings.TrimPrefix(name, "content."))
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range q[name] {
+			v := v
+			filters = append(filters, models.ContentFilter{Path: keys, Value: &v})
+		}
+	}
+
+	return filters, nil
+}
+
+func encodeCursor(c *models.BannerCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (*models.BannerCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var c models.BannerCursor
+	if err = json.Unmarshal(raw, &c); err != nil || c.ID <= 0 {
+		return nil, errInvalidCursor
+	}
+	switch c.SortBy {
+	case "banner_id", "created_at", "updated_at":
+	default:
+		return nil, errInvalidCursor
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/tracing"
+	"banner-service/internal/utils/responser"
+)
+
+const (
+	maxSearchQuery     = 200
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+func (h *BannerHandler) SearchBanners(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.Start(r.Context(), "BannerHandler.SearchBanners")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	h.log(r).Debug("search banners handler")
+
+	q := r.URL.Query()
+	search := models.BannerSearch{Query: strings.TrimSpace(q.Get("q")), Limit: defaultSearchLimit}
+	if search.Query == "" || utf8.RuneCountInString(search.Query) > maxSearchQuery {
+		responser.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("q is required and must not be longer than %d characters", maxSearchQuery))
+		return
+	}
+
+	var err error
+	for name, dst := range map[string]*int{"tag_id": &search.TagID, "feature_id": &search.FeatureID} {
+		if v := q.Get(name); v != "" {
+			if *dst, err = strconv.Atoi(v); err != nil {
+				responser.WriteError(w, http.StatusBadRequest, fmt.Errorf("incorrect %s", name))
+				return
+			}
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if search.Limit, err = strconv.Atoi(v); err != nil || search.Limit <= 0 || search.Limit > maxSearchLimit {
+			responser.WriteError(w, http.StatusBadRequest, fmt.Errorf("limit must be from 1 to %d", maxSearchLimit))
+			return
+		}
+	}
+
+	results, err := h.service.SearchBanners(r.Context(), search)
+	if err != nil {
+		h.log(r).Error("failed to search banners ", err)
+		responser.WriteError(w, http.StatusInternalServerError, errors.New("failed to search banners"))
+		return
+	}
+	if results == nil {
+		results = make([]models.BannerSearchResult, 0)
+	}
+
+	resultsJSON, _ := json.Marshal(results)
+	responser.WriteJSON(w, http.StatusOK, resultsJSON)
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"banner-service/internal/pkg/snapshot"
+	"banner-service/internal/pkg/tracing"
+	"banner-service/internal/utils/responser"
+)
+
+// WithSnapshotKey enables GetSnapshot, which signs the snapshots with key.
+func (h *BannerHandler) WithSnapshotKey(key ed25519.PrivateKey) *BannerHandler {
+	h.snapshotKey = key
+	return h
+}
+
+// GetSnapshot writes the signed snapshot edge nodes load, its version also goes in a header.
+func (h *BannerHandler) GetSnapshot(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.Start(r.Context(), "BannerHandler.GetSnapshot")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	h.log(r).Debug("get snapshot handler")
+
+	if h.snapshotKey == nil {
+		responser.WriteError(w, http.StatusNotFound, errors.New("snapshots are not enabled"))
+		return
+	}
+
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(transferTimeout))
+
+	snap, err := h.service.GetSnapshot(r.Context())
+	if err != nil {
+		h.log(r).Error("failed to get snapshot ", err)
+		responser.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	data, err := snapshot.Sign(snap, h.snapshotKey)
+	if err != nil {
+		h.log(r).Error("failed to sign snapshot ", err)
+		responser.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("X-Snapshot-Version", strconv.FormatInt(snap.Version, 10))
+	responser.WriteJSON(w, http.StatusOK, data)
+}
@@ -0,0 +1,331 @@
+package http
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/tracing"
+	"banner-service/internal/utils/responser"
+)
+
+const (
+	formatJSONL = "jsonl"
+	formatCSV   = "csv"
+
+	// transferTimeout replaces the server read and write timeouts, which are tuned for single banner requests.
+	transferTimeout   = 10 * time.Minute
+	maxImportSize     = 64 << 20
+	maxImportLineSize = 1 << 20
+	defaultChunkSize  = 500
+	flushEvery        = 100
+)
+
+var csvHeader = []string{
+	"banner_id", "tag_ids", "feature_id", "is_active", "version", "created_at", "updated_at", "content", "versions",
+}
+
+func (h *BannerHandler) ExportBanners(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.Start(r.Context(), "BannerHandler.ExportBanners")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	h.log(r).Debug("export banners handler")
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = formatJSONL
+	}
+	if format != formatJSONL && format != formatCSV {
+		responser.WriteError(w, http.StatusBadRequest, errors.New("format must be jsonl or csv"))
+		return
+	}
+
+	withVersions := false
+	if v := r.URL.Query().Get("versions"); v != "" {
+		var err error
+		if withVersions, err = strconv.ParseBool(v); err != nil {
+			responser.WriteError(w, http.StatusBadRequest, errors.New("incorrect versions"))
+			return
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Now().Add(transferTimeout))
+
+	var (
+		write func(models.BannerRecord) error
+		flush func() error
+	)
+	if format == formatCSV {
+		w.Header().Set("Content-Type", "text/csv")
+		cw := csv.NewWriter(w)
+		write = func(rec models.BannerRecord) error { return cw.Write(csvRow(rec)) }
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+		_ = cw.Write(csvHeader)
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(w)
+		write = func(rec models.BannerRecord) error { return enc.Encode(rec) }
+		flush = func() error { return nil }
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="banners.`+format+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	n := 0
+	err := h.service.ExportBanners(r.Context(), withVersions, func(rec models.BannerRecord) error {
+		if err := write(rec); err != nil {
+			return err
+		}
+		n++
+		if n%flushEvery == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			_ = rc.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		// The status is already sent, the client sees a truncated body.
+		h.log(r).Error("failed to export banners after ", n, " rows: ", err)
+		return
+	}
+
+	h.log(r).Info("exported ", n, " banners")
+}
+
+func (h *BannerHandler) ImportBanners(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.Start(r.Context(), "BannerHandler.ImportBanners")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	h.log(r).Debug("import banners handler")
+
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = formatJSONL
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+			format = formatCSV
+		}
+	}
+	if format != formatJSONL && format != formatCSV {
+		responser.WriteError(w, http.StatusBadRequest, errors.New("format must be jsonl or csv"))
+		return
+	}
+
+	opts := models.ImportOptions{ChunkSize: defaultChunkSize}
+	var err error
+	if v := q.Get("dry_run"); v != "" {
+		if opts.DryRun, err = strconv.ParseBool(v); err != nil {
+			responser.WriteError(w, http.StatusBadRequest, errors.New("incorrect dry_run"))
+			return
+		}
+	}
+	switch q.Get("mode") {
+	case "", "atomic":
+		opts.Atomic = true
+	case "chunked":
+	default:
+		responser.WriteError(w, http.StatusBadRequest, errors.New("mode must be atomic or chunked"))
+		return
+	}
+	if v := q.Get("chunk_size"); v != "" {
+		if opts.ChunkSize, err = strconv.Atoi(v); err != nil || opts.ChunkSize <= 0 {
+			responser.WriteError(w, http.StatusBadRequest, errors.New("incorrect chunk_size"))
+			return
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Now().Add(transferTimeout))
+	_ = rc.SetWriteDeadline(time.Now().Add(transferTimeout))
+
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	defer body.Close()
+
+	var lines []models.ImportLine
+	if format == formatCSV {
+		lines, err = readCSV(body)
+	} else {
+		lines, err = readJSONL(body)
+	}
+	if err != nil {
+		h.log(r).Warn("failed to read import: ", err)
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		responser.WriteError(w, status, fmt.Errorf("failed to read import: %w", err))
+		return
+	}
+
+	report, err := h.service.ImportBanners(r.Context(), lines, opts)
+	if err != nil {
+		h.log(r).Error("failed to import banners: ", err)
+		responser.WriteError(w, http.StatusInternalServerError, errors.New("failed to import banners"))
+		return
+	}
+
+	h.log(r).Info("imported banners: ", report.Committed, " committed, ", report.Failed, " failed")
+
+	status := http.StatusOK
+	if report.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	reportJSON, _ := json.Marshal(report)
+	responser.WriteJSON(w, status, reportJSON)
+}
+
+func readJSONL(body io.Reader) ([]models.ImportLine, error) {
+	sc := bufio.NewScanner(body)
+	sc.Buffer(make([]byte, 64<<10), maxImportLineSize)
+
+	var lines []models.ImportLine
+	for n := 1; sc.Scan(); n++ {
+		raw := strings.TrimSpace(sc.Text())
+		if raw == "" {
+			continue
+		}
+
+		l := models.ImportLine{Line: n}
+		if err := json.Unmarshal([]byte(raw), &l.Record); err != nil {
+			l.Err = fmt.Errorf("invalid json: %w", err)
+		}
+		lines = append(lines, l)
+	}
+
+	return lines, sc.Err()
+}
+
+func readCSV(body io.Reader) ([]models.ImportLine, error) {
+	cr := csv.NewReader(body)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, required := range []string{"tag_ids", "feature_id", "content"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %s", required)
+		}
+	}
+
+	var lines []models.ImportLine
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+
+		var l models.ImportLine
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			l.Line, l.Err = parseErr.StartLine, parseErr.Err
+		} else {
+			l.Line, _ = cr.FieldPos(0)
+			l.Record, l.Err = parseCSVRow(row, columns)
+		}
+		lines = append(lines, l)
+	}
+}
+
+func parseCSVRow(row []string, columns map[string]int) (models.BannerRecord, error) {
+	get := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	rec := models.BannerRecord{Banner: models.Banner{IsActive: true}}
+	var err error
+
+	for _, tag := range strings.Split(get("tag_ids"), ",") {
+		if tag = strings.TrimSpace(tag); tag == "" {
+			continue
+		}
+		id, err := strconv.Atoi(tag)
+		if err != nil {
+			return rec, fmt.Errorf("incorrect tag_ids: %w", err)
+		}
+		rec.TagIDs = append(rec.TagIDs, id)
+	}
+	if rec.FeatureID, err = strconv.Atoi(get("feature_id")); err != nil {
+		return rec, fmt.Errorf("incorrect feature_id: %w", err)
+	}
+	if v := get("is_active"); v != "" {
+		if rec.IsActive, err = strconv.ParseBool(v); err != nil {
+			return rec, fmt.Errorf("incorrect is_active: %w", err)
+		}
+	}
+	if v := get("version"); v != "" {
+		if rec.Version, err = strconv.Atoi(v); err != nil {
+			return rec, fmt.Errorf("incorrect version: %w", err)
+		}
+	}
+	for name, t := range map[string]*time.Time{"created_at": &rec.CreatedAt, "updated_at": &rec.UpdatedAt} {
+		if v := get(name); v != "" {
+			if *t, err = time.Parse(time.RFC3339Nano, v); err != nil {
+				return rec, fmt.Errorf("incorrect %s: %w", name, err)
+			}
+		}
+	}
+	rec.Content = json.RawMessage(get("content"))
+	if v := get("versions"); v != "" {
+		if err = json.Unmarshal([]byte(v), &rec.Versions); err != nil {
+			return rec, fmt.Errorf("incorrect versions: %w", err)
+		}
+	}
+
+	return rec, nil
+}
+
+func csvRow(rec models.BannerRecord) []string {
+	tags := make([]string, len(rec.TagIDs))
+	for i, id := range rec.TagIDs {
+		tags[i] = strconv.Itoa(id)
+	}
+
+	versions := ""
+	if len(rec.Versions) > 0 {
+		raw, _ := json.Marshal(rec.Versions)
+		versions = string(raw)
+	}
+
+	return []string{
+		strconv.Itoa(rec.BannerID),
+		strings.Join(tags, ","),
+		strconv.Itoa(rec.FeatureID),
+		strconv.FormatBool(rec.IsActive),
+		strconv.Itoa(rec.Version),
+		rec.CreatedAt.Format(time.RFC3339Nano),
+		rec.UpdatedAt.Format(time.RFC3339Nano),
+		string(rec.Content),
+		versions,
+	}
+}
@@ -5,25 +5,41 @@ import (
 	"context"
 )
 
-type BannerService interface {
+// BannerReader is what serving /api/user_banner needs, an edge node has nothing more.
+type BannerReader interface {
 	GetBanner(ctx context.Context, tagID, featureID int, useLastRevision bool, isAdmin bool) ([]byte, error)
-	GetFilterBanners(ctx context.Context, tagID, featureID, limit, offset int) ([]models.Banner, error)
+}
+
+type BannerService interface {
+	BannerReader
+	GetFilterBanners(ctx context.Context, filter models.BannerFilter) (models.BannerPage, error)
+	SearchBanners(ctx context.Context, search models.BannerSearch) ([]models.BannerSearchResult, error)
 	AddBanner(ctx context.Context, banner *models.BannerPayload) (int, error)
+	AddBanners(ctx context.Context, banners []models.BannerPayload, atomic bool) (models.BatchReport, error)
 	UpdateBanner(ctx context.Context, id int, banner *models.BannerPayload) error
 	DeleteBanner(ctx context.Context, id int) error
 	GetCurrentBanner(ctx context.Context, id int) (models.BannerVersion, error)
 	GetOldBanners(ctx context.Context, id int) ([]models.BannerVersion, error)
 	ChangeVersionOfBanner(ctx context.Context, id int, version int) error
+	CountActiveBanners(ctx context.Context) (int, error)
+	ExportBanners(ctx context.Context, withVersions bool, fn func(models.BannerRecord) error) error
+	ImportBanners(ctx context.Context, lines []models.ImportLine, opts models.ImportOptions) (models.ImportReport, error)
+	GetSnapshot(ctx context.Context) (models.BannerSnapshot, error)
 }
 
 type BannerRepository interface {
 	ReadBanner(ctx context.Context, tagID, featureID int) ([]byte, error)
 	ReadUserBanner(ctx context.Context, tagID, featureID int) ([]byte, error)
-	ReadFilterBanners(ctx context.Context, tagID, featureID, limit, offset int) ([]models.Banner, error)
+	ReadFilterBanners(ctx context.Context, filter models.BannerFilter) (models.BannerPage, error)
+	SearchBanners(ctx context.Context, search models.BannerSearch) ([]models.BannerSearchResult, error)
 	CreateBanner(ctx context.Context, banner *models.BannerPayload) (int, error)
+	CreateBanners(ctx context.Context, banners []models.BannerPayload, atomic bool) ([]models.BatchResult, error)
 	UpdateBanner(ctx context.Context, id int, banner *models.BannerPayload) error
 	DeleteBanner(ctx context.Context, id int) error
 	ReadCurrentBannerByID(ctx context.Context, id int) (models.BannerVersion, error)
 	ReadOldVersions(ctx context.Context, id int) ([]models.BannerVersion, error)
 	UpdateVersionOfBanner(ctx context.Context, id int, version int) error
+	CountActiveBanners(ctx context.Context) (int, error)
+	ExportBanners(ctx context.Context, withVersions bool, fn func(models.BannerRecord) error) error
+	ImportBanners(ctx context.Context, lines []models.ImportLine, opts models.ImportOptions) (models.ImportReport, error)
 }
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/tracing"
+)
+
+const (
+	batchKnownTags     = `SELECT tag_id FROM tag WHERE tag_id = ANY($1);`
+	batchKnownFeatures = `SELECT feature_id FROM feature WHERE feature_id = ANY($1);`
+	batchTakenPairs    = `SELECT tag_id, feature_id FROM banner_tag_feature
+                          WHERE (tag_id, feature_id) IN (SELECT * FROM unnest($1::int[], $2::int[]));`
+)
+
+// ErrBatchConflict means that a tag, feature or tag and feature pair of the batch was changed by another
+// request after the batch was checked, retrying the batch reports the affected items.
+var ErrBatchConflict = errors.New("batch conflicts with a concurrent change")
+
+type tagFeature struct {
+	tagID, featureID int
+}
+
+// CreateBanners writes the banners in one transaction. Items that would violate a constraint are found
+// up front and reported, with atomic nothing is written if there is any. The banner rows are inserted
+// with a pgx.Batch and their tags copied into banner_tag_feature.
+func (br *BannerRepository) CreateBanners(ctx context.Context, banners []models.BannerPayload,
+	atomic bool) ([]models.BatchResult, error) {
+	ctx, span := tracing.Start(ctx, "BannerRepository.CreateBanners")
+	defer span.End()
+
+	results := make([]models.BatchResult, len(banners))
+	for i := range results {
+		results[i].Index = i
+	}
+
+	tx, err := br.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	problems, err := checkBatch(ctx, tx, banners)
+	if err != nil {
+		return nil, err
+	}
+	for i, problem := range problems {
+		results[i].Error = problem
+	}
+	if len(problems) == len(banners) || (atomic && len(problems) > 0) {
+		return results, nil
+	}
+
+	batch := &pgx.Batch{}
+	for i, b := range banners {
+		if results[i].Error != "" {
+			continue
+		}
+		id := &results[i].BannerID
+		batch.Queue(createBanner, []byte(b.Content), b.IsActive.IsTrue).QueryRow(func(row pgx.Row) error {
+			return row.Scan(id)
+		})
+	}
+	if err = tx.SendBatch(ctx, batch).Close(); err != nil {
+		return nil, fmt.Errorf("failed to insert banners: %w", err)
+	}
+
+	var links [][]interface{}
+	for i, b := range banners {
+		if results[i].Error != "" {
+			continue
+		}
+		for _, tagID := range b.TagIDs {
+			links = append(links, []interface{}{results[i].BannerID, tagID, b.FeatureID})
+		}
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"banner_tag_feature"}, []string{"banner_id", "tag_id", "feature_id"},
+		pgx.CopyFromRows(links))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && (pgErr.Code == uniqueViolation || pgErr.Code == foreignKeyViolation) {
+			return nil, ErrBatchConflict
+		}
+		return nil, fmt.Errorf("failed to copy tags: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// checkBatch returns the problem of every banner that has an unknown tag or feature or a tag and feature
+// already used by another banner. The three lookups are sent in one round trip.
+func checkBatch(ctx context.Context, tx pgx.Tx, banners []models.BannerPayload) (map[int]string, error) {
+	var tags, features, pairTags, pairFeatures []int
+	for _, b := range banners {
+		features = append(features, b.FeatureID)
+		for _, tagID := range b.TagIDs {
+			tags = append(tags, tagID)
+			pairTags = append(pairTags, tagID)
+			pairFeatures = append(pairFeatures, b.FeatureID)
+		}
+	}
+
+	knownTags := make(map[int]bool, len(tags))
+	knownFeatures := make(map[int]bool, len(features))
+	taken := make(map[tagFeature]bool)
+
+	batch := &pgx.Batch{}
+	batch.Queue(batchKnownTags, tags).Query(func(rows pgx.Rows) error {
+		var id int
+		_, err := pgx.ForEachRow(rows, []any{&id}, func() error {
+			knownTags[id] = true
+			return nil
+		})
+		return err
+	})
+	batch.Queue(batchKnownFeatures, features).Query(func(rows pgx.Rows) error {
+		var id int
+		_, err := pgx.ForEachRow(rows, []any{&id}, func() error {
+			knownFeatures[id] = true
+			return nil
+		})
+		return err
+	})
+	batch.Queue(batchTakenPairs, pairTags, pairFeatures).Query(func(rows pgx.Rows) error {
+		var pair tagFeature
+		_, err := pgx.ForEachRow(rows, []any{&pair.tagID, &pair.featureID}, func() error {
+			taken[pair] = true
+			return nil
+		})
+		return err
+	})
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return nil, fmt.Errorf("failed to check banners: %w", err)
+	}
+
+	problems := make(map[int]string)
+	for i, b := range banners {
+		if !knownFeatures[b.FeatureID] {
+			problems[i] = fmt.Sprintf("unknown feature %d", b.FeatureID)
+			continue
+		}
+		for _, tagID := range b.TagIDs {
+			if !knownTags[tagID] {
+				problems[i] = fmt.Sprintf("unknown tag %d", tagID)
+				break
+			}
+			if taken[tagFeature{tagID, b.FeatureID}] {
+				problems[i] = fmt.Sprintf("a banner with tag %d and feature %d already exists", tagID, b.FeatureID)
+				break
+			}
+		}
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/tracing"
+)
+
+// The tags of a banner are aggregated per row in a lateral subquery, so that an ordered listing walks the
+// index of the sort column and stops at the limit instead of grouping every banner first.
+const (
+	listBanners = `SELECT b.banner_id, b.content, b.is_active, b.created_at, b.updated_at, l.feature_id, l.tag_ids
+                   FROM banner b
+                   CROSS JOIN LATERAL (SELECT min(feature_id) AS feature_id,
+                                              array_agg(tag_id ORDER BY tag_id) AS tag_ids
+                                       FROM banner_tag_feature WHERE banner_id = b.banner_id) l
+                   WHERE l.feature_id IS NOT NULL%s
+                   ORDER BY %s`
+	countListBanners = `SELECT count(*) FROM banner b
+                        WHERE EXISTS (SELECT 1 FROM banner_tag_feature t WHERE t.banner_id = b.banner_id)%s;`
+)
+
+type bannerQuery struct {
+	conds []string
+	args  []interface{}
+}
+
+func (q *bannerQuery) arg(v interface{}) string {
+	q.args = append(q.args, v)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+func (q *bannerQuery) where(cond string) {
+	q.conds = append(q.conds, cond)
+}
+
+func (q *bannerQuery) clause() string {
+	if len(q.conds) == 0 {
+		return ""
+	}
+	return " AND " + strings.Join(q.conds, " AND ")
+}
+
+func filterBanners(f models.BannerFilter) *bannerQuery {
+	q := &bannerQuery{}
+	if f.TagID != 0 {
+		q.where("EXISTS (SELECT 1 FROM banner_tag_feature t WHERE t.banner_id = b.banner_id AND t.tag_id = " +
+			q.arg(f.TagID) + ")")
+	}
+	if f.FeatureID != 0 {
+		q.where("EXISTS (SELECT 1 FROM banner_tag_feature t WHERE t.banner_id = b.banner_id AND t.feature_id = " +
+			q.arg(f.FeatureID) + ")")
+	}
+	if f.IsActive != nil {
+		q.where("b.is_active = " + q.arg(*f.IsActive))
+	}
+	for _, r := range []struct {
+		cond string
+		zero bool
+		v    interface{}
+	}{
+		{"b.created_at >= ", f.CreatedFrom.IsZero(), f.CreatedFrom},
+		{"b.created_at < ", f.CreatedTo.IsZero(), f.CreatedTo},
+		{"b.updated_at >= ", f.UpdatedFrom.IsZero(), f.UpdatedFrom},
+		{"b.updated_at < ", f.UpdatedTo.IsZero(), f.UpdatedTo},
+	} {
+		if !r.zero {
+			q.where(r.cond + q.arg(r.v))
+		}
+	}
+	for _, c := range f.Content {
+		q.where(contentCond(q, c))
+	}
+
+	return q
+}
+
+// contentCond matches a key with jsonpath and a value with containment, both use index_banner_content.
+func contentCond(q *bannerQuery, c models.ContentFilter) string {
+	if c.Value == nil {
+		return "b.content @? " + q.arg(`$."`+strings.Join(c.Path, `"."`)+`"`) + "::jsonpath"
+	}
+
+	text, _ := json.Marshal(*c.Value)
+	cond := "b.content @> " + q.arg(contentObject(c.Path, text)) + "::jsonb"
+	var typed interface{}
+	if err := json.Unmarshal([]byte(*c.Value), &typed); err == nil {
+		if _, isString := typed.(string); !isString {
+			cond = "(" + cond + " OR b.content @> " + q.arg(contentObject(c.Path, json.RawMessage(*c.Value))) +
+				"::jsonb)"
+		}
+	}
+	return cond
+}
+
+func contentObject(path []string, value json.RawMessage) string {
+	obj := value
+	for i := len(path) - 1; i >= 0; i-- {
+		obj, _ = json.Marshal(map[string]json.RawMessage{path[i]: obj})
+	}
+	return string(obj)
+}
+
+func sortColumn(sortBy string) (string, error) {
+	switch sortBy {
+	case "", "banner_id":
+		return "b.banner_id", nil
+	case "created_at":
+		return "b.created_at", nil
+	case "updated_at":
+		return "b.updated_at", nil
+	}
+	return "", fmt.Errorf("unknown sort column %q", sortBy)
+}
+
+// ReadFilterBanners returns a page of banners with their feature and all their tags, a tag filter matches
+// a banner that has the tag among others. The page and the total are read in one round trip. Limit 0
+// means no limit.
+func (br *BannerRepository) ReadFilterBanners(ctx context.Context, f models.BannerFilter) (models.BannerPage, error) {
+	ctx, span := tracing.Start(ctx, "BannerRepository.ReadFilterBanners")
+	defer span.End()
+
+	column, err := sortColumn(f.SortBy)
+	if err != nil {
+		return models.BannerPage{}, err
+	}
+
+	q := filterBanners(f)
+	countSQL := fmt.Sprintf(countListBanners, q.clause())
+	countArgs := append([]interface{}(nil), q.args...)
+
+	dir, cmp := "ASC", ">"
+	if f.Desc {
+		dir, cmp = "DESC", "<"
+	}
+	order := "b.banner_id " + dir
+	if column != "b.banner_id" {
+		order = column + " " + dir + ", " + order
+	}
+
+	if f.After != nil {
+		if column == "b.banner_id" {
+			q.where("b.banner_id " + cmp + " " + q.arg(f.After.ID))
+		} else {
+			value, id := q.arg(f.After.Value), q.arg(f.After.ID)
+			q.where("(" + column + ", b.banner_id) " + cmp + " (" + value + ", " + id + ")")
+		}
+	}
+
+	listSQL := fmt.Sprintf(listBanners, q.clause(), order)
+	if f.Limit > 0 {
+		// One more row tells whether there is a next page.
+		listSQL += " LIMIT " + q.arg(f.Limit+1)
+	}
+	if f.Offset > 0 {
+		listSQL += " OFFSET " + q.arg(f.Offset)
+	}
+
+	var page models.BannerPage
+	err = br.reads.Read(ctx, func(db *pgxpool.Pool) error {
+		page = models.BannerPage{Banners: make([]models.Banner, 0)}
+		batch := &pgx.Batch{}
+		batch.Queue(listSQL, q.args...).Query(func(rows pgx.Rows) error {
+			for rows.Next() {
+				var b models.Banner
+				err := rows.Scan(&b.BannerID, &b.Content, &b.IsActive, &b.CreatedAt, &b.UpdatedAt, &b.FeatureID,
+					&b.TagIDs)
+				if err != nil {
+					return fmt.Errorf("error happened in rows.Scan: %w", err)
+				}
+				page.Banners = append(page.Banners, b)
+			}
+			return rows.Err()
+		})
+		batch.Queue(countSQL, countArgs...).QueryRow(func(row pgx.Row) error {
+			return row.Scan(&page.Total)
+		})
+		return db.SendBatch(ctx, batch).Close()
+	})
+	if err != nil {
+		return models.BannerPage{Banners: make([]models.Banner, 0)}, err
+	}
+
+	if f.Limit > 0 && len(page.Banners) > f.Limit {
+		page.Banners = page.Banners[:f.Limit]
+		page.Next = nextCursor(f, column, page.Banners[f.Limit-1])
+	}
+
+	return page, nil
+}
+
+func nextCursor(f models.BannerFilter, column string, last models.Banner) *models.BannerCursor {
+	next := &models.BannerCursor{SortBy: f.SortBy, Desc: f.Desc, ID: last.BannerID}
+	switch column {
+	case "b.created_at":
+		next.Value = last.CreatedAt
+	case "b.updated_at":
+		next.Value = last.UpdatedAt
+	}
+	if next.SortBy == "" {
+		next.SortBy = "banner_id"
+	}
+	return next
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/memstore"
+	"banner-service/internal/pkg/tracing"
+)
+
+// maxTotalVersions is the number of versions createVersion keeps, the current one included.
+const maxTotalVersions = 3
+
+// MemoryBannerRepository keeps the banners in a memstore.Store with the semantics of BannerRepository.
+type MemoryBannerRepository struct {
+	store *memstore.Store
+}
+
+func NewMemoryBannerRepository(store *memstore.Store) *MemoryBannerRepository {
+	return &MemoryBannerRepository{store: store}
+}
+
+func (mr *MemoryBannerRepository) ReadUserBanner(ctx context.Context, tagID, featureID int) ([]byte, error) {
+	_, span := tracing.Start(ctx, "MemoryBannerRepository.ReadUserBanner")
+	defer span.End()
+
+	b, err := mr.readBanner(tagID, featureID)
+	if err != nil {
+		return nil, err
+	}
+	if !b.IsActive {
+		return nil, ErrBannerNotFound
+	}
+
+	return b.Content, nil
+}
+
+func (mr *MemoryBannerRepository) ReadBanner(ctx context.Context, tagID, featureID int) ([]byte, error) {
+	_, span := tracing.Start(ctx, "MemoryBannerRepository.ReadBanner")
+	defer span.End()
+
+	b, err := mr.readBanner(tagID, featureID)
+	return b.Content, err
+}
+
+func (mr *MemoryBannerRepository) readBanner(tagID, featureID int) (memstore.Banner, error) {
+	var b memstore.Banner
+	err := mr.store.Read(func(tx *memstore.Tx) error {
+		id, ok := tx.BannerID(memstore.Pair{TagID: tagID, FeatureID: featureID})
+		if !ok {
+			return ErrBannerNotFound
+		}
+		b, _ = tx.Banner(id)
+		return nil
+	})
+	return b, err
+}
+
+func (mr *MemoryBannerRepository) CreateBanner(ctx context.Context, banner *models.BannerPayload) (int, error) {
+	_, span := tracing.Start(ctx, "MemoryBannerRepository.CreateBanner")
+	defer span.End()
+
+	var id int
+	err := mr.store.Write(func(tx *memstore.Tx) error {
+		now := memstore.Now()
+		id = tx.NextBannerID()
+		return tx.PutBanner(memstore.Banner{
+			BannerID:       id,
+			TagIDs:         banner.TagIDs,
+			FeatureID:      banner.FeatureID,
+			Content:        banner.Content,
+			IsActive:       banner.IsActive.IsTrue,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+			CurrentVersion: 1,
+			TotalVersions:  1,
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// UpdateBanner replaces the tags of the banner with the new ones, under the new feature or the one it has.
+func (mr *MemoryBannerRepository) UpdateBanner(ctx context.Context, id int, banner *models.BannerPayload) error {
+	_, span := tracing.Start(ctx, "MemoryBannerRepository.UpdateBanner")
+	defer span.End()
+
+	return mr.store.Write(func(tx *memstore.Tx) error {
+		b, ok := tx.Banner(id)
+		if !ok {
+			return ErrBannerNotFound
+		}
+
+		if banner.Content != nil {
+			newVersion(&b)
+			b.Content = banner.Content
+		}
+		if banner.IsActive.HasValue {
+			b.IsActive = banner.IsActive.IsTrue
+		}
+		b.UpdatedAt = memstore.Now()
+
+		switch {
+		case banner.TagIDs != nil:
+			if banner.FeatureID != 0 {
+				b.FeatureID = banner.FeatureID
+			}
+			b.TagIDs = banner.TagIDs
+		case banner.FeatureID != 0 && len(b.TagIDs) > 0:
+			b.FeatureID = banner.FeatureID
+		}
+
+		return tx.PutBanner(b)
+	})
+}
+
+// newVersion keeps the current content as an old version the way createVersion does.
+func newVersion(b *memstore.Banner) {
+	current := models.BannerVersion{
+		Version:   b.CurrentVersion,
+		Content:   b.Content,
+		CreatedAt: b.CreatedAt,
+		UpdatedAt: b.UpdatedAt,
+	}
+	b.Versions = append(b.Versions, current)
+
+	total := b.TotalVersions
+	if total >= maxTotalVersions {
+		kept := b.Versions[:0]
+		for _, v := range b.Versions {
+			if v.Version != current.Version-1 {
+				kept = append(kept, v)
+			}
+		}
+		b.Versions = kept
+		total--
+	}
+
+	b.CurrentVersion = current.Version + 1
+	b.TotalVersions = total + 1
+}
+
+func (mr *MemoryBannerRepository) DeleteBanner(ctx context.Context, id int) error {
+	_, span := tracing.Start(ctx, "MemoryBannerRepository.DeleteBanner")
+	defer span.End()
+
+	return mr.store.Write(func(tx *memstore.Tx) error {
+		deleted, err := tx.DeleteBanner(id)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return ErrBannerNotFound
+		}
+		return nil
+	})
+}
+
+func (mr *MemoryBannerRepository) ReadCurrentBannerByID(ctx context.Context, id int) (models.BannerVersion, error) {
+	_, span := tracing.Start(ctx, "MemoryBannerRepository.ReadCurrentBannerByID")
+	defer span.End()
+
+	var current models.BannerVersion
+	err := mr.store.Read(func(tx *memstore.Tx) error {
+		b, ok := tx.Banner(id)
+		if !ok {
+			return ErrBannerNotFound
+		}
+		current = models.BannerVersion{
+			Version:   b.CurrentVersion,
+			Content:   b.Content,
+			CreatedAt: b.CreatedAt,
+			UpdatedAt: b.UpdatedAt,
+		}
+		return nil
+	})
+	return current, err
+}
+
+func (mr *MemoryBannerRepository) ReadOldVersions(ctx context.Context, id int) ([]models.BannerVersion, error) {
+	_, span := tracing.Start(ctx, "MemoryBannerRepository.ReadOldVersions")
+	defer span.End()
+
+	versions := make([]models.BannerVersion, 0)
+	err := mr.store.Read(func(tx *memstore.Tx) error {
+		if b, ok := tx.Banner(id); ok {
+			versions = append(versions, b.Versions...)
+		}
+		return nil
+	})
+	return versions, err
+}
+
+// UpdateVersionOfBanner makes the old version current and drops it and every version after it.
+func (mr *MemoryBannerRepository) UpdateVersionOfBanner(ctx context.Context, id int, version int) error {
+	_, span := tracing.Start(ctx, "MemoryBannerRepository.UpdateVersionOfBanner")
+	defer span.End()
+
+	return mr.store.Write(func(tx *memstore.Tx) error {
+		b, ok := tx.Banner(id)
+		if !ok {
+			return ErrBannerNotFound
+		}
+
+		var (
+			target models.BannerVersion
+			found  bool
+		)
+		kept := make([]models.BannerVersion, 0, len(b.Versions))
+		for _, v := range b.Versions {
+			switch {
+			case v.Version == version:
+				target, found = v, true
+			case v.Version < version:
+				kept = append(kept, v)
+			}
+		}
+		if !found {
+			return ErrBannerNotFound
+		}
+
+		b.TotalVersions -= len(b.Versions) - len(kept)
+		b.Versions = kept
+		b.Content = target.Content
+		b.CurrentVersion = version
+		b.CreatedAt, b.UpdatedAt = target.CreatedAt, target.UpdatedAt
+		return tx.PutBanner(b)
+	})
+}
+
+func (mr *MemoryBannerRepository) CountActiveBanners(ctx context.Context) (int, error) {
+	_, span := tracing.Start(ctx, "MemoryBannerRepository.CountActiveBanners")
+	defer span.End()
+
+	var count int
+	err := mr.store.Read(func(tx *memstore.Tx) error {
+		tx.Banners(func(b *memstore.Banner) bool {
+			if b.IsActive {
+				count++
+			}
+			return true
+		})
+		return nil
+	})
+	return count, err
+}
@@ -0,0 +1,359 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"html"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/memstore"
+	"banner-service/internal/pkg/tracing"
+)
+
+// The weights ts_rank gives to the title, text and url of a banner, see migration 0003.
+var (
+	searchWeights = map[string]float64{"title": 1, "text": 0.4, "url": 0.2}
+	searchWord    = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+)
+
+func (mr *MemoryBannerRepository) ReadFilterBanners(ctx context.Context,
+	f models.BannerFilter) (models.BannerPage, error) {
+	_, span := tracing.Start(ctx, "MemoryBannerRepository.ReadFilterBanners")
+	defer span.End()
+
+	column, err := sortColumn(f.SortBy)
+	if err != nil {
+		return models.BannerPage{}, err
+	}
+
+	var matched []models.Banner
+	err = mr.store.Read(func(tx *memstore.Tx) error {
+		tx.Banners(func(b *memstore.Banner) bool {
+			if matchFilter(b, f) {
+				matched = append(matched, listedBanner(b))
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		return models.BannerPage{Banners: make([]models.Banner, 0)}, err
+	}
+
+	key := func(b models.Banner) int64 {
+		switch column {
+		case "b.created_at":
+			return b.CreatedAt.UnixMicro()
+		case "b.updated_at":
+			return b.UpdatedAt.UnixMicro()
+		}
+		return 0
+	}
+	// before tells whether a comes before b in the order of the listing.
+	before := func(a, b models.Banner) bool {
+		ka, kb := key(a), key(b)
+		if ka == kb {
+			return (a.BannerID < b.BannerID) != f.Desc
+		}
+		return (ka < kb) != f.Desc
+	}
+	sort.Slice(matched, func(i, j int) bool { return before(matched[i], matched[j]) })
+
+	page := models.BannerPage{Banners: make([]models.Banner, 0), Total: len(matched)}
+	if f.After != nil {
+		after := models.Banner{BannerID: f.After.ID, CreatedAt: f.After.Value, UpdatedAt: f.After.Value}
+		start := sort.Search(len(matched), func(i int) bool { return before(after, matched[i]) })
+		matched = matched[start:]
+	}
+	matched = matched[min(f.Offset, len(matched)):]
+
+	if f.Limit > 0 && len(matched) > f.Limit {
+		page.Banners = append(page.Banners, matched[:f.Limit]...)
+		page.Next = nextCursor(f, column, page.Banners[f.Limit-1])
+		return page, nil
+	}
+	page.Banners = append(page.Banners, matched...)
+	return page, nil
+}
+
+func listedBanner(b *memstore.Banner) models.Banner {
+	return models.Banner{
+		BannerID:  b.BannerID,
+		TagIDs:    append([]int(nil), b.TagIDs...),
+		FeatureID: b.FeatureID,
+		Content:   b.Content,
+		IsActive:  b.IsActive,
+		CreatedAt: b.CreatedAt,
+		UpdatedAt: b.UpdatedAt,
+	}
+}
+
+// matchFilter is filterBanners for a banner in memory, a banner without tags is never listed.
+func matchFilter(b *memstore.Banner, f models.BannerFilter) bool {
+	if len(b.TagIDs) == 0 ||
+		(f.TagID != 0 && !hasTag(b.TagIDs, f.TagID)) ||
+		(f.FeatureID != 0 && b.FeatureID != f.FeatureID) ||
+		(f.IsActive != nil && b.IsActive != *f.IsActive) {
+		return false
+	}
+	if (!f.CreatedFrom.IsZero() && b.CreatedAt.Before(f.CreatedFrom)) ||
+		(!f.CreatedTo.IsZero() && !b.CreatedAt.Before(f.CreatedTo)) ||
+		(!f.UpdatedFrom.IsZero() && b.UpdatedAt.Before(f.UpdatedFrom)) ||
+		(!f.UpdatedTo.IsZero() && !b.UpdatedAt.Before(f.UpdatedTo)) {
+		return false
+	}
+	if len(f.Content) == 0 {
+		return true
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(b.Content, &doc); err != nil {
+		return false
+	}
+	for _, c := range f.Content {
+		if !matchContent(doc, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasTag looks for the tag in the sorted tags of a banner.
+func hasTag(tagIDs []int, tagID int) bool {
+	i := sort.SearchInts(tagIDs, tagID)
+	return i < len(tagIDs) && tagIDs[i] == tagID
+}
+
+// matchContent is contentCond: a key is looked up like a lax jsonpath and a value is matched like @>.
+func matchContent(doc interface{}, c models.ContentFilter) bool {
+	if c.Value == nil {
+		return hasPath(doc, c.Path)
+	}
+
+	text, _ := json.Marshal(*c.Value)
+	var want interface{}
+	_ = json.Unmarshal([]byte(contentObject(c.Path, text)), &want)
+	if jsonContains(doc, want) {
+		return true
+	}
+
+	var typed interface{}
+	if err := json.Unmarshal([]byte(*c.Value), &typed); err == nil {
+		if _, isString := typed.(string); !isString {
+			_ = json.Unmarshal([]byte(contentObject(c.Path, json.RawMessage(*c.Value))), &want)
+			return jsonContains(doc, want)
+		}
+	}
+	return false
+}
+
+func hasPath(node interface{}, path []string) bool {
+	if len(path) == 0 {
+		return true
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		v, ok := n[path[0]]
+		return ok && hasPath(v, path[1:])
+	case []interface{}:
+		for _, e := range n {
+			if obj, ok := e.(map[string]interface{}); ok && hasPath(obj, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonContains tells whether a contains b as jsonb @> does for values that are not top-level arrays.
+func jsonContains(a, b interface{}) bool {
+	switch bv := b.(type) {
+	case map[string]interface{}:
+		av, ok := a.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range bv {
+			if x, ok := av[k]; !ok || !jsonContains(x, v) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		av, ok := a.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, v := range bv {
+			found := false
+			for _, x := range av {
+				if jsonContains(x, v) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// SearchBanners matches the words of the query, "quoted phrases" and -excluded words like
+// websearch_to_tsquery with the simple configuration, except that or is read as and. The ranks only order
+// the results, they are not the values ts_rank gives.
+func (mr *MemoryBannerRepository) SearchBanners(ctx context.Context,
+	search models.BannerSearch) ([]models.BannerSearchResult, error) {
+	_, span := tracing.Start(ctx, "MemoryBannerRepository.SearchBanners")
+	defer span.End()
+
+	include, exclude := parseSearch(search.Query)
+	if len(include) == 0 {
+		return []models.BannerSearchResult{}, nil
+	}
+
+	filter := models.BannerFilter{TagID: search.TagID, FeatureID: search.FeatureID}
+	results := make([]models.BannerSearchResult, 0)
+	err := mr.store.Read(func(tx *memstore.Tx) error {
+		tx.Banners(func(b *memstore.Banner) bool {
+			if matchFilter(b, filter) {
+				if r, ok := searchBanner(b, include, exclude); ok {
+					results = append(results, r)
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Rank > results[j].Rank })
+	if search.Limit > 0 && len(results) > search.Limit {
+		results = results[:search.Limit]
+	}
+	return results, nil
+}
+
+// parseSearch returns the phrases a banner must have and those it must not, a word is a phrase of one.
+func parseSearch(query string) ([][]string, [][]string) {
+	var include, exclude [][]string
+	for i, part := range strings.Split(query, `"`) {
+		quoted := i%2 == 1
+		if quoted {
+			if words := searchWords(part); len(words) > 0 {
+				include = append(include, words)
+			}
+			continue
+		}
+		for _, field := range strings.Fields(part) {
+			negated := strings.HasPrefix(field, "-")
+			for _, w := range searchWords(field) {
+				if strings.EqualFold(w, "or") {
+					continue
+				}
+				if negated {
+					exclude = append(exclude, []string{w})
+				} else {
+					include = append(include, []string{w})
+				}
+			}
+		}
+	}
+	return include, exclude
+}
+
+func searchWords(s string) []string {
+	return searchWord.FindAllString(strings.ToLower(s), -1)
+}
+
+func searchBanner(b *memstore.Banner, include, exclude [][]string) (models.BannerSearchResult, bool) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b.Content, &fields); err != nil {
+		return models.BannerSearchResult{}, false
+	}
+
+	text := make(map[string]string, len(searchWeights))
+	words := make(map[string][]string, len(searchWeights))
+	for key := range searchWeights {
+		if s, ok := fields[key].(string); ok {
+			text[key], words[key] = s, searchWords(s)
+		}
+	}
+
+	r := models.BannerSearchResult{Banner: listedBanner(b), Highlights: make(map[string]string)}
+	for _, phrase := range exclude {
+		for key := range words {
+			if hasPhrase(words[key], phrase) {
+				return r, false
+			}
+		}
+	}
+
+	marked := make(map[string]map[string]bool)
+	for _, phrase := range include {
+		best := 0.0
+		for key, weight := range searchWeights {
+			if !hasPhrase(words[key], phrase) {
+				continue
+			}
+			best = max(best, weight)
+			if marked[key] == nil {
+				marked[key] = make(map[string]bool)
+			}
+			for _, w := range phrase {
+				marked[key][w] = true
+			}
+		}
+		if best == 0 {
+			return r, false
+		}
+		r.Rank += best
+	}
+
+	for key, ws := range marked {
+		r.Highlights[key] = highlight(text[key], ws)
+	}
+	return r, true
+}
+
+// highlight escapes s for HTML and puts the marked words in <mark>, the tags are the only markup in it.
+func highlight(s string, marked map[string]bool) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range searchWord.FindAllStringIndex(s, -1) {
+		w := s[loc[0]:loc[1]]
+		if !marked[strings.ToLower(w)] {
+			continue
+		}
+		b.WriteString(html.EscapeString(s[last:loc[0]]))
+		b.WriteString(highlightStart + html.EscapeString(w) + highlightStop)
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(s[last:]))
+	return b.String()
+}
+
+func hasPhrase(words, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		match := true
+		for j, w := range phrase {
+			if words[i+j] != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
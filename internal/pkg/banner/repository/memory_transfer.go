@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/memstore"
+	"banner-service/internal/pkg/tracing"
+)
+
+// errDiscard rolls back a memstore transaction that has nothing wrong but must not be committed.
+var errDiscard = errors.New("discard transaction")
+
+// CreateBanners reports the items checkBatch would report and, unless that stops the batch, writes the
+// rest in one transaction.
+func (mr *MemoryBannerRepository) CreateBanners(ctx context.Context, banners []models.BannerPayload,
+	atomic bool) ([]models.BatchResult, error) {
+	_, span := tracing.Start(ctx, "MemoryBannerRepository.CreateBanners")
+	defer span.End()
+
+	results := make([]models.BatchResult, len(banners))
+	for i := range results {
+		results[i].Index = i
+	}
+
+	err := mr.store.Write(func(tx *memstore.Tx) error {
+		failed := 0
+		for i, b := range banners {
+			if results[i].Error = checkBatchItem(tx, b); results[i].Error != "" {
+				failed++
+			}
+		}
+		if failed == len(banners) || (atomic && failed > 0) {
+			return nil
+		}
+
+		now := memstore.Now()
+		for i, b := range banners {
+			if results[i].Error != "" {
+				continue
+			}
+			results[i].BannerID = tx.NextBannerID()
+			err := tx.PutBanner(memstore.Banner{
+				BannerID:       results[i].BannerID,
+				TagIDs:         b.TagIDs,
+				FeatureID:      b.FeatureID,
+				Content:        b.Content,
+				IsActive:       b.IsActive.IsTrue,
+				CreatedAt:      now,
+				UpdatedAt:      now,
+				CurrentVersion: 1,
+				TotalVersions:  1,
+			})
+			if errors.Is(err, memstore.ErrUniqueViolation) || errors.Is(err, memstore.ErrForeignKeyViolation) {
+				return ErrBatchConflict
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func checkBatchItem(tx *memstore.Tx, b models.BannerPayload) string {
+	if !tx.Feature(b.FeatureID) {
+		return fmt.Sprintf("unknown feature %d", b.FeatureID)
+	}
+	for _, tagID := range b.TagIDs {
+		if !tx.Tag(tagID) {
+			return fmt.Sprintf("unknown tag %d", tagID)
+		}
+		if _, taken := tx.BannerID(memstore.Pair{TagID: tagID, FeatureID: b.FeatureID}); taken {
+			return fmt.Sprintf("a banner with tag %d and feature %d already exists", tagID, b.FeatureID)
+		}
+	}
+	return ""
+}
+
+// ExportBanners copies the banners before calling fn, a slow reader does not hold up the writes.
+func (mr *MemoryBannerRepository) ExportBanners(ctx context.Context, withVersions bool,
+	fn func(models.BannerRecord) error) error {
+	_, span := tracing.Start(ctx, "MemoryBannerRepository.ExportBanners")
+	defer span.End()
+
+	var records []models.BannerRecord
+	err := mr.store.Read(func(tx *memstore.Tx) error {
+		tx.Banners(func(b *memstore.Banner) bool {
+			r := models.BannerRecord{Banner: listedBanner(b), Version: b.CurrentVersion,
+				Versions: make([]models.BannerVersion, 0)}
+			if r.TagIDs == nil {
+				r.TagIDs = make([]int, 0)
+			}
+			if withVersions {
+				r.Versions = append(r.Versions, b.Versions...)
+			}
+			records = append(records, r)
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if err = fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportBanners commits like the Postgres ImportBanners, a failed line is rolled back to its savepoint.
+func (mr *MemoryBannerRepository) ImportBanners(ctx context.Context, lines []models.ImportLine,
+	opts models.ImportOptions) (models.ImportReport, error) {
+	_, span := tracing.Start(ctx, "MemoryBannerRepository.ImportBanners")
+	defer span.End()
+
+	report := models.ImportReport{DryRun: opts.DryRun, Atomic: opts.Atomic, Errors: []models.ImportError{}}
+
+	chunk := opts.ChunkSize
+	if opts.Atomic || chunk <= 0 {
+		chunk = len(lines)
+	}
+
+	for start := 0; start < len(lines); start += chunk {
+		end := min(start+chunk, len(lines))
+
+		var (
+			succeeded int
+			failed    []models.ImportError
+		)
+		err := mr.store.Write(func(tx *memstore.Tx) error {
+			for _, l := range lines[start:end] {
+				if err := tx.Savepoint(func() error { return importMemoryRecord(tx, l.Record) }); err != nil {
+					failed = append(failed, models.ImportError{Line: l.Line, Error: describeImportError(err)})
+					continue
+				}
+				succeeded++
+			}
+			if opts.DryRun || (opts.Atomic && len(failed) > 0) {
+				return errDiscard
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errDiscard) {
+			return report, err
+		}
+
+		report.Succeeded += succeeded
+		report.Failed += len(failed)
+		report.Errors = append(report.Errors, failed...)
+		if err == nil {
+			report.Committed += succeeded
+		}
+	}
+
+	return report, nil
+}
+
+func importMemoryRecord(tx *memstore.Tx, r models.BannerRecord) error {
+	timestamp := func(t time.Time) time.Time {
+		if t.IsZero() {
+			return memstore.Now()
+		}
+		return memstore.Timestamp(t)
+	}
+
+	versions := make([]models.BannerVersion, 0, len(r.Versions))
+	for _, v := range r.Versions {
+		v.CreatedAt, v.UpdatedAt = memstore.Timestamp(v.CreatedAt), memstore.Timestamp(v.UpdatedAt)
+		versions = append(versions, v)
+	}
+
+	return tx.PutBanner(memstore.Banner{
+		BannerID:       tx.NextBannerID(),
+		TagIDs:         r.TagIDs,
+		FeatureID:      r.FeatureID,
+		Content:        r.Content,
+		IsActive:       r.IsActive,
+		CreatedAt:      timestamp(r.CreatedAt),
+		UpdatedAt:      timestamp(r.UpdatedAt),
+		CurrentVersion: max(r.Version, 1),
+		TotalVersions:  len(r.Versions) + 1,
+		Versions:       versions,
+	})
+}
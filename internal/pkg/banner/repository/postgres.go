@@ -5,29 +5,21 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"strconv"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"banner-service/internal/models"
+	"banner-service/internal/pkg/replica"
+	"banner-service/internal/pkg/tracing"
 )
 
 const (
-	getBannerIDByTagFeature    = `SELECT banner_id FROM banner_tag_feature WHERE tag_id=$1 AND feature_id=$2;`
-	getBannerIDsByTag          = `SELECT DISTINCT banner_id FROM banner_tag_feature WHERE tag_id=$1`
-	getBannerIDsByFeature      = `SELECT banner_id FROM banner_tag_feature WHERE feature_id=$1`
-	getAllBannerIDs            = `SELECT banner_id FROM banner_tag_feature`
-	getActiveBannerContentByID = `SELECT content FROM banner WHERE banner_id=$1 AND is_active=TRUE;`
-	getBannerContentByID       = `SELECT content FROM banner WHERE banner_id=$1;`
-	getBannerByID              = `SELECT content, is_active, created_at, updated_at FROM banner WHERE banner_id=$1;`
-	getFeatureForBanner        = `SELECT feature_id FROM banner_tag_feature WHERE banner_id=$1;`
-	getTagsForBanner           = `SELECT tag_id FROM banner_tag_feature WHERE banner_id=$1;`
-	getFeatureTagsForBanner    = `SELECT tag_id, feature_id FROM banner_tag_feature WHERE banner_id=$1;`
-	createBanner               = `INSERT INTO banner(content, is_active) VALUES ($1, $2) RETURNING banner_id;`
-	createFeatureAndTag        = `INSERT INTO banner_tag_feature(banner_id, tag_id, feature_id) VALUES ($1, $2, $3);`
-	updateBanner               = `UPDATE banner SET content = COALESCE($1, content),
+	getFeatureTagsForBanner = `SELECT tag_id, feature_id FROM banner_tag_feature WHERE banner_id=$1;`
+	createBanner            = `INSERT INTO banner(content, is_active) VALUES ($1, $2) RETURNING banner_id;`
+	createFeatureAndTag     = `INSERT INTO banner_tag_feature(banner_id, tag_id, feature_id) VALUES ($1, $2, $3);`
+	updateBanner            = `UPDATE banner SET content = COALESCE($1, content),
                 			         is_active= COALESCE($2, is_active), 
                                      updated_at = now() 
 					                 WHERE banner_id = $3;`
@@ -52,153 +44,91 @@ const (
 	getVersionOfBanner = `SELECT content, created_at, updated_at FROM banner_version WHERE banner_id=$1 
                                           AND "version"=$2;`
 	deleteGreaterAndEqualBannerVersion = `DELETE FROM banner_version WHERE banner_id=$1 AND "version">=$2;`
+	countActiveBanners                 = `SELECT count(*) FROM banner WHERE is_active=TRUE;`
 	updateCurrentBannerVersion         = `UPDATE banner SET content=$1, current_version=$2, 
                   									created_at=$3, updated_at=$4, total_versions=total_versions-$5 
               										WHERE banner_id=$6;`
+	getBannerByTagFeature = `SELECT b.content, b.is_active FROM banner_tag_feature btf
+                                  JOIN banner b ON b.banner_id = btf.banner_id
+                                  WHERE btf.tag_id=$1 AND btf.feature_id=$2;`
 )
 
 var (
 	ErrBannerNotFound = errors.New("banner not found")
 )
 
+// stmtBannerByTagFeature is the name getBannerByTagFeature is prepared under by Prepare.
+const stmtBannerByTagFeature = "banner_by_tag_feature"
+
+// Prepare registers the named statements of the user banner path on a new connection, the pool passed
+// to NewBannerRepository must run it as pgxpool.Config.AfterConnect.
+func Prepare(ctx context.Context, conn *pgx.Conn) error {
+	if _, err := conn.Prepare(ctx, stmtBannerByTagFeature, getBannerByTagFeature); err != nil {
+		return fmt.Errorf("failed to prepare %s: %w", stmtBannerByTagFeature, err)
+	}
+	return nil
+}
+
 type BannerRepository struct {
-	db *pgxpool.Pool
+	db    *pgxpool.Pool
+	reads *replica.Router
 }
 
 func NewBannerRepository(db *pgxpool.Pool) *BannerRepository {
-	return &BannerRepository{db: db}
+	return &BannerRepository{db: db, reads: replica.NewRouter(db, nil, nil)}
+}
+
+// WithReplicas sends the user banner, list, search and count reads through reads, writes stay on the primary.
+func (br *BannerRepository) WithReplicas(reads *replica.Router) *BannerRepository {
+	br.reads = reads
+	return br
 }
 
 func (br *BannerRepository) ReadUserBanner(ctx context.Context, tagID, featureID int) ([]byte, error) {
-	var bannerID int
-	if err := br.db.QueryRow(ctx, getBannerIDByTagFeature, tagID, featureID).
-		Scan(&bannerID); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrBannerNotFound
-		}
-		return []byte{}, err
-	}
+	ctx, span := tracing.Start(ctx, "BannerRepository.ReadUserBanner")
+	defer span.End()
 
-	var b []byte
-	if err := br.db.QueryRow(ctx, getActiveBannerContentByID, bannerID).
-		Scan(&b); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrBannerNotFound
-		}
-		return []byte{}, err
+	content, isActive, err := br.readBanner(ctx, tagID, featureID)
+	if err != nil {
+		return nil, err
+	}
+	if !isActive {
+		return nil, ErrBannerNotFound
 	}
 
-	return b, nil
+	return content, nil
 }
 
 func (br *BannerRepository) ReadBanner(ctx context.Context, tagID, featureID int) ([]byte, error) {
-	var bannerID int
-	if err := br.db.QueryRow(ctx, getBannerIDByTagFeature, tagID, featureID).
-		Scan(&bannerID); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrBannerNotFound
-		}
-		return []byte{}, err
-	}
+	ctx, span := tracing.Start(ctx, "BannerRepository.ReadBanner")
+	defer span.End()
 
-	var b []byte
-	if err := br.db.QueryRow(ctx, getBannerContentByID, bannerID).
-		Scan(&b); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrBannerNotFound
-		}
-		return []byte{}, err
-	}
-
-	return b, nil
+	content, _, err := br.readBanner(ctx, tagID, featureID)
+	return content, err
 }
 
-func (br *BannerRepository) ReadFilterBanners(ctx context.Context,
-	tagID, featureID, limit, offset int) ([]models.Banner, error) {
-	endOfExp := ""
-
-	if limit != 0 {
-		endOfExp = endOfExp + " LIMIT " + strconv.Itoa(limit)
-	}
-
-	if offset != 0 {
-		endOfExp = endOfExp + " OFFSET " + strconv.Itoa(offset)
-	}
-
-	endOfExp += ";"
-
-	var rows pgx.Rows
-	var err error
-	if tagID != 0 && featureID != 0 {
-		if offset != 0 {
-			return make([]models.Banner, 0), nil
-		}
-
-		rows, err = br.db.Query(ctx, getBannerIDByTagFeature, tagID, featureID)
-	} else if tagID != 0 {
-		rows, err = br.db.Query(ctx, getBannerIDsByTag+endOfExp, tagID)
-	} else if featureID != 0 {
-		rows, err = br.db.Query(ctx, getBannerIDsByFeature+endOfExp, featureID)
-	} else {
-		rows, err = br.db.Query(ctx, getAllBannerIDs+endOfExp)
-	}
-
+func (br *BannerRepository) readBanner(ctx context.Context, tagID, featureID int) ([]byte, bool, error) {
+	var (
+		content  []byte
+		isActive bool
+	)
+	err := br.reads.Read(ctx, func(db *pgxpool.Pool) error {
+		return db.QueryRow(ctx, stmtBannerByTagFeature, tagID, featureID).Scan(&content, &isActive)
+	})
 	if err != nil {
-		return make([]models.Banner, 0), err
-	}
-
-	var bannerID int
-	banners := make([]models.Banner, 0)
-	for rows.Next() {
-		err = rows.Scan(&bannerID)
-		if err != nil {
-			err = fmt.Errorf("error happened in rows.Scan: %w", err)
-
-			return make([]models.Banner, 0), err
-		}
-
-		var banner models.Banner
-		banner.BannerID = bannerID
-
-		if err = br.db.QueryRow(ctx, getBannerByID, bannerID).
-			Scan(&banner.Content, &banner.IsActive, &banner.CreatedAt, &banner.UpdatedAt); err != nil {
-			return make([]models.Banner, 0), err
-		}
-
-		if err = br.db.QueryRow(ctx, getFeatureForBanner, bannerID).
-			Scan(&banner.FeatureID); err != nil {
-			return make([]models.Banner, 0), err
-		}
-
-		var tagsRows pgx.Rows
-		tagsRows, err = br.db.Query(ctx, getTagsForBanner, bannerID)
-
-		if err != nil {
-			return make([]models.Banner, 0), err
-		}
-
-		tags := make([]int, 0)
-		for tagsRows.Next() {
-			var tagIDOfRow int
-			err = tagsRows.Scan(&tagIDOfRow)
-
-			if err != nil {
-				err = fmt.Errorf("error happened in rows.Scan: %w", err)
-				return make([]models.Banner, 0), err
-			}
-
-			tags = append(tags, tagIDOfRow)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, ErrBannerNotFound
 		}
-
-		banner.TagIDs = tags
-
-		banners = append(banners, banner)
+		return nil, false, err
 	}
 
-	return banners, nil
+	return content, isActive, nil
 }
 
 func (br *BannerRepository) CreateBanner(ctx context.Context, banner *models.BannerPayload) (int, error) {
+	ctx, span := tracing.Start(ctx, "BannerRepository.CreateBanner")
+	defer span.End()
+
 	bannerID := 0
 	tx, err := br.db.Begin(ctx)
 	if err != nil {
@@ -213,13 +143,13 @@ func (br *BannerRepository) CreateBanner(ctx context.Context, banner *models.Ban
 		}
 	}()
 
-	err = br.db.QueryRow(ctx, createBanner, banner.Content, banner.IsActive.IsTrue).Scan(&bannerID)
+	err = tx.QueryRow(ctx, createBanner, banner.Content, banner.IsActive.IsTrue).Scan(&bannerID)
 	if err != nil {
 		return 0, err
 	}
 
 	for _, val := range banner.TagIDs {
-		_, err = br.db.Exec(ctx, createFeatureAndTag, bannerID, val, banner.FeatureID)
+		_, err = tx.Exec(ctx, createFeatureAndTag, bannerID, val, banner.FeatureID)
 		if err != nil {
 			return 0, err
 		}
@@ -229,6 +159,9 @@ func (br *BannerRepository) CreateBanner(ctx context.Context, banner *models.Ban
 }
 
 func (br *BannerRepository) UpdateBanner(ctx context.Context, id int, banner *models.BannerPayload) error {
+	ctx, span := tracing.Start(ctx, "BannerRepository.UpdateBanner")
+	defer span.End()
+
 	var cmdTag pgconn.CommandTag
 	tx, err := br.db.Begin(ctx)
 	if err != nil {
@@ -244,49 +177,47 @@ func (br *BannerRepository) UpdateBanner(ctx context.Context, id int, banner *mo
 	}()
 
 	if banner.Content != nil {
-		err = br.createVersion(ctx, id)
+		err = br.createVersion(ctx, tx, id)
 		if err != nil {
 			return err
 		}
 	}
 
 	if !banner.IsActive.HasValue {
-		cmdTag, err = br.db.Exec(ctx, updateBanner, banner.Content, sql.NullBool{}, id)
+		cmdTag, err = tx.Exec(ctx, updateBanner, banner.Content, sql.NullBool{}, id)
 	} else {
-		cmdTag, err = br.db.Exec(ctx, updateBanner, banner.Content, banner.IsActive.IsTrue, id)
+		cmdTag, err = tx.Exec(ctx, updateBanner, banner.Content, banner.IsActive.IsTrue, id)
 	}
 
-	if cmdTag.RowsAffected() == 0 {
-		return ErrBannerNotFound
+	if err != nil {
+		return err
 	}
 
-	if err != nil {
+	if cmdTag.RowsAffected() == 0 {
+		err = ErrBannerNotFound
 		return err
 	}
 
 	if banner.TagIDs != nil {
-		var rows pgx.Rows
-		rows, err = br.db.Query(ctx, getFeatureTagsForBanner, id)
+		var pairs [][2]int
+		pairs, err = readTagFeatures(ctx, tx, id)
 		if err != nil {
 			return err
 		}
 
-		var tagID, featureID, cnt int
-		for rows.Next() {
-			err = rows.Scan(&tagID, &featureID)
-			if err != nil {
-				return err
-			}
+		cnt := 0
+		for _, pair := range pairs {
+			tagID, featureID := pair[0], pair[1]
 			if banner.FeatureID == 0 {
 				banner.FeatureID = featureID
 			}
 			if cnt < len(banner.TagIDs) {
-				_, err = br.db.Exec(ctx, updateTagFeatureForBanner, banner.TagIDs[cnt], banner.FeatureID, tagID, featureID)
+				_, err = tx.Exec(ctx, updateTagFeatureForBanner, banner.TagIDs[cnt], banner.FeatureID, tagID, featureID)
 				if err != nil {
 					return err
 				}
 			} else {
-				_, err = br.db.Exec(ctx, deleteTagFeatureForBanner, tagID, featureID)
+				_, err = tx.Exec(ctx, deleteTagFeatureForBanner, tagID, featureID)
 				if err != nil {
 					return err
 				}
@@ -295,19 +226,42 @@ func (br *BannerRepository) UpdateBanner(ctx context.Context, id int, banner *mo
 		}
 
 		for cnt < len(banner.TagIDs) {
-			_, err = br.db.Exec(ctx, createFeatureAndTag, id, banner.TagIDs[cnt], banner.FeatureID)
+			_, err = tx.Exec(ctx, createFeatureAndTag, id, banner.TagIDs[cnt], banner.FeatureID)
 			if err != nil {
 				return err
 			}
 			cnt++
 		}
 	} else if banner.FeatureID != 0 {
-		_, err = br.db.Exec(ctx, updateFeatureForBanner, banner.FeatureID, id)
+		_, err = tx.Exec(ctx, updateFeatureForBanner, banner.FeatureID, id)
 	}
 	return err
 }
 
+// readTagFeatures reads the tags and features of the banner before they are changed, the connection of
+// the transaction cannot run a statement while rows are open.
+func readTagFeatures(ctx context.Context, tx pgx.Tx, id int) ([][2]int, error) {
+	rows, err := tx.Query(ctx, getFeatureTagsForBanner, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pairs [][2]int
+	for rows.Next() {
+		var pair [2]int
+		if err = rows.Scan(&pair[0], &pair[1]); err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, rows.Err()
+}
+
 func (br *BannerRepository) DeleteBanner(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "BannerRepository.DeleteBanner")
+	defer span.End()
+
 	cmdTag, err := br.db.Exec(ctx, deleteBanner, id)
 	if cmdTag.RowsAffected() == 0 {
 		return ErrBannerNotFound
@@ -316,20 +270,24 @@ func (br *BannerRepository) DeleteBanner(ctx context.Context, id int) error {
 	return err
 }
 
-func (br *BannerRepository) createVersion(ctx context.Context, id int) error {
+func (br *BannerRepository) createVersion(ctx context.Context, tx pgx.Tx, id int) error {
+	ctx, span := tracing.Start(ctx, "BannerRepository.createVersion")
+	defer span.End()
+
 	var oldVersion models.BannerVersion
 	var totalVersions int
 
-	err := br.db.QueryRow(ctx, readCurrentVersion, id).Scan(&oldVersion.Version, &totalVersions,
+	err := tx.QueryRow(ctx, readCurrentVersion, id).Scan(&oldVersion.Version, &totalVersions,
 		&oldVersion.Content, &oldVersion.CreatedAt, &oldVersion.UpdatedAt)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return ErrBannerNotFound
 		}
+		return err
 	}
 
-	_, err = br.db.Exec(ctx, createVersion, id, oldVersion.Version, oldVersion.Content,
+	_, err = tx.Exec(ctx, createVersion, id, oldVersion.Version, oldVersion.Content,
 		oldVersion.CreatedAt, oldVersion.UpdatedAt)
 
 	if err != nil {
@@ -337,7 +295,7 @@ func (br *BannerRepository) createVersion(ctx context.Context, id int) error {
 	}
 
 	if totalVersions >= 3 {
-		_, err = br.db.Exec(ctx, deleteVersion, id, oldVersion.Version-1)
+		_, err = tx.Exec(ctx, deleteVersion, id, oldVersion.Version-1)
 		if err != nil {
 			return err
 		}
@@ -345,11 +303,14 @@ func (br *BannerRepository) createVersion(ctx context.Context, id int) error {
 		totalVersions -= 1
 	}
 
-	_, err = br.db.Exec(ctx, updateVersionOfBanner, oldVersion.Version+1, totalVersions+1, id)
+	_, err = tx.Exec(ctx, updateVersionOfBanner, oldVersion.Version+1, totalVersions+1, id)
 	return err
 }
 
 func (br *BannerRepository) ReadCurrentBannerByID(ctx context.Context, id int) (models.BannerVersion, error) {
+	ctx, span := tracing.Start(ctx, "BannerRepository.ReadCurrentBannerByID")
+	defer span.End()
+
 	var banner models.BannerVersion
 	err := br.db.QueryRow(ctx, getCurrentVersion, id).Scan(&banner.Version,
 		&banner.Content, &banner.CreatedAt, &banner.UpdatedAt)
@@ -360,6 +321,9 @@ func (br *BannerRepository) ReadCurrentBannerByID(ctx context.Context, id int) (
 }
 
 func (br *BannerRepository) ReadOldVersions(ctx context.Context, id int) ([]models.BannerVersion, error) {
+	ctx, span := tracing.Start(ctx, "BannerRepository.ReadOldVersions")
+	defer span.End()
+
 	banners := make([]models.BannerVersion, 0)
 	rows, err := br.db.Query(ctx, getOldVersions, id)
 
@@ -375,6 +339,9 @@ func (br *BannerRepository) ReadOldVersions(ctx context.Context, id int) ([]mode
 }
 
 func (br *BannerRepository) UpdateVersionOfBanner(ctx context.Context, id int, version int) error {
+	ctx, span := tracing.Start(ctx, "BannerRepository.UpdateVersionOfBanner")
+	defer span.End()
+
 	tx, err := br.db.Begin(ctx)
 	if err != nil {
 		return err
@@ -389,19 +356,33 @@ func (br *BannerRepository) UpdateVersionOfBanner(ctx context.Context, id int, v
 	}()
 
 	var newVersion models.BannerVersion
-	err = br.db.QueryRow(ctx, getVersionOfBanner, id, version).Scan(&newVersion.Content,
+	err = tx.QueryRow(ctx, getVersionOfBanner, id, version).Scan(&newVersion.Content,
 		&newVersion.CreatedAt, &newVersion.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrBannerNotFound
+			err = ErrBannerNotFound
 		}
-		return nil
+		return err
 	}
 
 	var cmdTag pgconn.CommandTag
-	cmdTag, err = br.db.Exec(ctx, deleteGreaterAndEqualBannerVersion, id, version)
+	cmdTag, err = tx.Exec(ctx, deleteGreaterAndEqualBannerVersion, id, version)
+	if err != nil {
+		return err
+	}
 
-	_, err = br.db.Exec(ctx, updateCurrentBannerVersion, newVersion.Content, version,
+	_, err = tx.Exec(ctx, updateCurrentBannerVersion, newVersion.Content, version,
 		newVersion.CreatedAt, newVersion.UpdatedAt, cmdTag.RowsAffected(), id)
 	return err
 }
+
+func (br *BannerRepository) CountActiveBanners(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "BannerRepository.CountActiveBanners")
+	defer span.End()
+
+	var count int
+	err := br.reads.Read(ctx, func(db *pgxpool.Pool) error {
+		return db.QueryRow(ctx, countActiveBanners).Scan(&count)
+	})
+	return count, err
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/tracing"
+)
+
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+	// ts_headline marks the matches with these private use characters, the content is escaped before they are
+	// replaced with the HTML tags.
+	headlineStart = "\ue000"
+	headlineStop  = "\ue001"
+	// searchBanners matches banner.search, kept up to date by a trigger, see migration 0003.
+	searchBanners = `SELECT b.banner_id, b.content, b.is_active, b.created_at, b.updated_at, l.feature_id, l.tag_ids,
+                            ts_rank(b.search, s.query) AS rank,
+                            ts_headline('simple', coalesce(b.content ->> 'title', ''), s.query, %[3]s),
+                            ts_headline('simple', coalesce(b.content ->> 'text', ''), s.query, %[3]s),
+                            ts_headline('simple', coalesce(b.content ->> 'url', ''), s.query, %[3]s)
+                     FROM banner b
+                     CROSS JOIN (SELECT websearch_to_tsquery('simple', %[1]s) AS query) s
+                     CROSS JOIN LATERAL (SELECT min(feature_id) AS feature_id,
+                                                array_agg(tag_id ORDER BY tag_id) AS tag_ids
+                                         FROM banner_tag_feature WHERE banner_id = b.banner_id) l
+                     WHERE b.search @@ s.query AND l.feature_id IS NOT NULL%[2]s
+                     ORDER BY rank DESC, b.banner_id
+                     LIMIT %[4]s;`
+	headlineOptions = `'StartSel="` + headlineStart + `", StopSel="` + headlineStop +
+		`", MaxFragments=2, MaxWords=20, MinWords=5'`
+)
+
+var headlineMarks = strings.NewReplacer(headlineStart, highlightStart, headlineStop, highlightStop)
+
+// SearchBanners returns the banners whose title, text or url match the query, best ranked first.
+func (br *BannerRepository) SearchBanners(ctx context.Context,
+	search models.BannerSearch) ([]models.BannerSearchResult, error) {
+	ctx, span := tracing.Start(ctx, "BannerRepository.SearchBanners")
+	defer span.End()
+
+	q := filterBanners(models.BannerFilter{TagID: search.TagID, FeatureID: search.FeatureID})
+	query := q.arg(search.Query)
+	limit := q.arg(search.Limit)
+
+	var results []models.BannerSearchResult
+	err := br.reads.Read(ctx, func(db *pgxpool.Pool) error {
+		rows, err := db.Query(ctx, fmt.Sprintf(searchBanners, query, q.clause(), headlineOptions, limit), q.args...)
+		if err != nil {
+			return err
+		}
+		results, err = pgx.CollectRows(rows, scanSearchResult)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func scanSearchResult(row pgx.CollectableRow) (models.BannerSearchResult, error) {
+	var (
+		r                 models.BannerSearchResult
+		title, text, link string
+	)
+	err := row.Scan(&r.BannerID, &r.Content, &r.IsActive, &r.CreatedAt, &r.UpdatedAt, &r.FeatureID, &r.TagIDs,
+		&r.Rank, &title, &text, &link)
+	if err != nil {
+		return r, fmt.Errorf("error happened in rows.Scan: %w", err)
+	}
+
+	// ts_headline returns the start of the text when the key has no match.
+	r.Highlights = make(map[string]string, 3)
+	for key, h := range map[string]string{"title": title, "text": text, "url": link} {
+		if strings.Contains(h, headlineStart) {
+			r.Highlights[key] = headlineMarks.Replace(html.EscapeString(h))
+		}
+	}
+	return r, nil
+}
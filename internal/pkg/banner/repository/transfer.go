@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/memstore"
+	"banner-service/internal/pkg/tracing"
+)
+
+const (
+	exportBanners = `SELECT b.banner_id, b.content, b.is_active, b.created_at, b.updated_at, b.current_version,
+                            COALESCE(array_agg(btf.tag_id ORDER BY btf.tag_id) FILTER (WHERE btf.tag_id IS NOT NULL), '{}'),
+                            COALESCE(min(btf.feature_id), 0), %s
+                     FROM banner b LEFT JOIN banner_tag_feature btf ON btf.banner_id = b.banner_id
+                     GROUP BY b.banner_id ORDER BY b.banner_id;`
+	exportVersions = `(SELECT COALESCE(json_agg(json_build_object(
+                             'version', v.version, 'content', v.content,
+                             'created_at', v.created_at::timestamptz, 'updated_at', v.updated_at::timestamptz)
+                         ORDER BY v.version), '[]') FROM banner_version v WHERE v.banner_id = b.banner_id)`
+	importBanner = `INSERT INTO banner(content, is_active, current_version, total_versions, created_at, updated_at)
+                    VALUES ($1, $2, $3, $4, COALESCE($5, now()), COALESCE($6, now())) RETURNING banner_id;`
+
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// ExportBanners streams every banner to fn in id order without loading them all in memory.
+func (br *BannerRepository) ExportBanners(ctx context.Context, withVersions bool,
+	fn func(models.BannerRecord) error) error {
+	ctx, span := tracing.Start(ctx, "BannerRepository.ExportBanners")
+	defer span.End()
+
+	versions := "'[]'::json"
+	if withVersions {
+		versions = exportVersions
+	}
+
+	rows, err := br.db.Query(ctx, fmt.Sprintf(exportBanners, versions))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			r   models.BannerRecord
+			raw []byte
+		)
+		err = rows.Scan(&r.BannerID, &r.Content, &r.IsActive, &r.CreatedAt, &r.UpdatedAt, &r.Version,
+			&r.TagIDs, &r.FeatureID, &raw)
+		if err != nil {
+			return fmt.Errorf("error happened in rows.Scan: %w", err)
+		}
+
+		if r.Versions, err = decodeVersions(raw); err != nil {
+			return err
+		}
+
+		if err = fn(r); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func decodeVersions(raw []byte) ([]models.BannerVersion, error) {
+	versions := make([]models.BannerVersion, 0)
+	if err := json.Unmarshal(raw, &versions); err != nil {
+		return nil, fmt.Errorf("error happened in json.Unmarshal: %w", err)
+	}
+
+	return versions, nil
+}
+
+// ImportBanners creates a banner for every line. Each line runs in a savepoint so that a failed line is
+// reported without aborting the rest; see models.ImportOptions for when transactions are committed.
+func (br *BannerRepository) ImportBanners(ctx context.Context, lines []models.ImportLine,
+	opts models.ImportOptions) (models.ImportReport, error) {
+	ctx, span := tracing.Start(ctx, "BannerRepository.ImportBanners")
+	defer span.End()
+
+	report := models.ImportReport{DryRun: opts.DryRun, Atomic: opts.Atomic, Errors: []models.ImportError{}}
+
+	chunk := opts.ChunkSize
+	if opts.Atomic || chunk <= 0 {
+		chunk = len(lines)
+	}
+
+	for start := 0; start < len(lines); start += chunk {
+		end := min(start+chunk, len(lines))
+
+		succeeded, failed, committed, err := br.importChunk(ctx, lines[start:end], opts)
+		if err != nil {
+			return report, err
+		}
+
+		report.Succeeded += succeeded
+		report.Failed += len(failed)
+		report.Errors = append(report.Errors, failed...)
+		if committed {
+			report.Committed += succeeded
+		}
+	}
+
+	return report, nil
+}
+
+func (br *BannerRepository) importChunk(ctx context.Context, lines []models.ImportLine,
+	opts models.ImportOptions) (int, []models.ImportError, bool, error) {
+	tx, err := br.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, false, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var (
+		succeeded int
+		failed    []models.ImportError
+	)
+	for _, l := range lines {
+		if err = importRecord(ctx, tx, l.Record); err != nil {
+			if ctx.Err() != nil {
+				return 0, nil, false, ctx.Err()
+			}
+			failed = append(failed, models.ImportError{Line: l.Line, Error: describeImportError(err)})
+			continue
+		}
+		succeeded++
+	}
+
+	if opts.DryRun || (opts.Atomic && len(failed) > 0) {
+		return succeeded, failed, false, nil
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return 0, nil, false, err
+	}
+
+	return succeeded, failed, true, nil
+}
+
+func importRecord(ctx context.Context, tx pgx.Tx, r models.BannerRecord) error {
+	return pgx.BeginFunc(ctx, tx, func(sp pgx.Tx) error {
+		version := max(r.Version, 1)
+
+		var id int
+		err := sp.QueryRow(ctx, importBanner, []byte(r.Content), r.IsActive, version, len(r.Versions)+1,
+			nullTime(r.CreatedAt), nullTime(r.UpdatedAt)).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		for _, tagID := range r.TagIDs {
+			if _, err = sp.Exec(ctx, createFeatureAndTag, id, tagID, r.FeatureID); err != nil {
+				return err
+			}
+		}
+
+		for _, v := range r.Versions {
+			_, err = sp.Exec(ctx, createVersion, id, v.Version, []byte(v.Content), v.CreatedAt, v.UpdatedAt)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func describeImportError(err error) string {
+	var pgErr *pgconn.PgError
+	isPgErr := errors.As(err, &pgErr)
+	switch {
+	case isPgErr && pgErr.Code == uniqueViolation, errors.Is(err, memstore.ErrUniqueViolation):
+		return "a banner with this tag and feature already exists"
+	case isPgErr && pgErr.Code == foreignKeyViolation, errors.Is(err, memstore.ErrForeignKeyViolation):
+		return "unknown tag or feature"
+	}
+	return err.Error()
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
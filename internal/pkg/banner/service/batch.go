@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/tracing"
+)
+
+var errPayloadActive = errors.New("is_active is required")
+
+// AddBanners validates every banner before any is written. With atomic the repository is not called
+// when a banner is invalid, otherwise only the valid banners are passed on.
+func (bs *BannerService) AddBanners(ctx context.Context, banners []models.BannerPayload,
+	atomic bool) (models.BatchReport, error) {
+	ctx, span := tracing.Start(ctx, "BannerService.AddBanners")
+	defer span.End()
+
+	report := models.BatchReport{Atomic: atomic, Results: make([]models.BatchResult, len(banners))}
+
+	var (
+		valid []models.BannerPayload
+		index []int
+	)
+	used := make(map[[2]int]int)
+	for i, b := range banners {
+		report.Results[i].Index = i
+		if err := validateBatchItem(b, i, used); err != nil {
+			report.Results[i].Error = err.Error()
+			continue
+		}
+		valid = append(valid, b)
+		index = append(index, i)
+	}
+
+	if len(valid) > 0 && (!atomic || len(valid) == len(banners)) {
+		results, err := bs.repo.CreateBanners(ctx, valid, atomic)
+		if err != nil {
+			return models.BatchReport{}, err
+		}
+		for j, res := range results {
+			res.Index = index[j]
+			report.Results[index[j]] = res
+		}
+	}
+
+	for _, res := range report.Results {
+		switch {
+		case res.Error != "":
+			report.Failed++
+		case res.BannerID != 0:
+			report.Created++
+		}
+	}
+
+	return report, nil
+}
+
+// validateBatchItem also rejects a tag and feature pair that an earlier banner of the batch uses.
+func validateBatchItem(b models.BannerPayload, i int, used map[[2]int]int) error {
+	switch {
+	case len(b.Content) == 0 || !json.Valid(b.Content):
+		return errRecordContent
+	case !b.IsActive.HasValue:
+		return errPayloadActive
+	case len(b.TagIDs) == 0:
+		return errRecordTags
+	case b.FeatureID <= 0:
+		return errRecordFeature
+	}
+
+	tags := make(map[int]bool, len(b.TagIDs))
+	for _, tagID := range b.TagIDs {
+		if tags[tagID] {
+			return fmt.Errorf("tag %d is listed twice", tagID)
+		}
+		tags[tagID] = true
+		if j, ok := used[[2]int{tagID, b.FeatureID}]; ok {
+			return fmt.Errorf("tag %d and feature %d are already used by item %d", tagID, b.FeatureID, j)
+		}
+	}
+	for _, tagID := range b.TagIDs {
+		used[[2]int{tagID, b.FeatureID}] = i
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+
+	"banner-service/internal/pkg/banner/repository"
+	"banner-service/internal/pkg/snapshot"
+	"banner-service/internal/pkg/tracing"
+)
+
+// EdgeService is the banner.BannerReader of an edge node, it answers from the snapshot table.
+type EdgeService struct {
+	table *snapshot.Table
+}
+
+func NewEdgeService(table *snapshot.Table) *EdgeService {
+	return &EdgeService{table: table}
+}
+
+// GetBanner serves admins from the snapshot as well, it has the active banners only and no later revision.
+func (es *EdgeService) GetBanner(ctx context.Context, tagID, featureID int, _ bool, _ bool) ([]byte, error) {
+	_, span := tracing.Start(ctx, "EdgeService.GetBanner")
+	defer span.End()
+
+	content, ok := es.table.Lookup(tagID, featureID)
+	if !ok {
+		return nil, repository.ErrBannerNotFound
+	}
+	return content, nil
+}
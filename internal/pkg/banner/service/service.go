@@ -4,6 +4,8 @@ import (
 	"banner-service/internal/models"
 	"banner-service/internal/pkg/banner"
 	"banner-service/internal/pkg/cache"
+	"banner-service/internal/pkg/replica"
+	"banner-service/internal/pkg/tracing"
 	"context"
 	"errors"
 	"strconv"
@@ -20,6 +22,9 @@ func NewBannerService(repo banner.BannerRepository, cache *cache.RedisClient) *B
 
 func (bs *BannerService) GetBanner(ctx context.Context, tagID, featureID int,
 	useLastRevision bool, isAdmin bool) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "BannerService.GetBanner")
+	defer span.End()
+
 	var banner []byte
 	ok := false
 	key := strconv.Itoa(tagID) + "-" + strconv.Itoa(featureID)
@@ -27,6 +32,10 @@ func (bs *BannerService) GetBanner(ctx context.Context, tagID, featureID int,
 	if !useLastRevision && bs.cache != nil {
 		banner, ok = bs.cache.Get(ctx, key)
 	}
+	if useLastRevision {
+		// Replicas may not have the latest revision yet.
+		ctx = replica.WithPrimary(ctx)
+	}
 
 	var err error
 	if isAdmin {
@@ -36,7 +45,7 @@ func (bs *BannerService) GetBanner(ctx context.Context, tagID, featureID int,
 		}
 
 		if bs.cache != nil {
-			bs.cache.Set(key, banner)
+			bs.cache.Set(ctx, key, banner)
 		}
 		return banner, nil
 	}
@@ -48,22 +57,27 @@ func (bs *BannerService) GetBanner(ctx context.Context, tagID, featureID int,
 		}
 
 		if bs.cache != nil {
-			bs.cache.Set(key, banner)
+			bs.cache.Set(ctx, key, banner)
 		}
 	}
 	return banner, nil
 }
 
-func (bs *BannerService) GetFilterBanners(ctx context.Context,
-	tagID, featureID, limit, offset int) ([]models.Banner, error) {
-	banners, err := bs.repo.ReadFilterBanners(ctx, tagID, featureID, limit, offset)
+func (bs *BannerService) GetFilterBanners(ctx context.Context, filter models.BannerFilter) (models.BannerPage, error) {
+	ctx, span := tracing.Start(ctx, "BannerService.GetFilterBanners")
+	defer span.End()
+
+	page, err := bs.repo.ReadFilterBanners(ctx, filter)
 	if err != nil {
-		return nil, err
+		return models.BannerPage{}, err
 	}
-	return banners, nil
+	return page, nil
 }
 
 func (bs *BannerService) AddBanner(ctx context.Context, banner *models.BannerPayload) (int, error) {
+	ctx, span := tracing.Start(ctx, "BannerService.AddBanner")
+	defer span.End()
+
 	if banner.Content == nil || !banner.IsActive.HasValue || banner.TagIDs == nil || banner.FeatureID == 0 {
 		return 0, errors.New("has empty fields")
 	}
@@ -77,26 +91,56 @@ func (bs *BannerService) AddBanner(ctx context.Context, banner *models.BannerPay
 }
 
 func (bs *BannerService) UpdateBanner(ctx context.Context, id int, banner *models.BannerPayload) error {
+	ctx, span := tracing.Start(ctx, "BannerService.UpdateBanner")
+	defer span.End()
+
 	err := bs.repo.UpdateBanner(ctx, id, banner)
 	return err
 }
 
 func (bs *BannerService) DeleteBanner(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "BannerService.DeleteBanner")
+	defer span.End()
+
 	err := bs.repo.DeleteBanner(ctx, id)
 	return err
 }
 
 func (bs *BannerService) GetCurrentBanner(ctx context.Context, id int) (models.BannerVersion, error) {
+	ctx, span := tracing.Start(ctx, "BannerService.GetCurrentBanner")
+	defer span.End()
+
 	currentVersion, err := bs.repo.ReadCurrentBannerByID(ctx, id)
 	return currentVersion, err
 }
 
 func (bs *BannerService) GetOldBanners(ctx context.Context, id int) ([]models.BannerVersion, error) {
+	ctx, span := tracing.Start(ctx, "BannerService.GetOldBanners")
+	defer span.End()
+
 	oldVersions, err := bs.repo.ReadOldVersions(ctx, id)
 	return oldVersions, err
 }
 
 func (bs *BannerService) ChangeVersionOfBanner(ctx context.Context, id int, version int) error {
+	ctx, span := tracing.Start(ctx, "BannerService.ChangeVersionOfBanner")
+	defer span.End()
+
 	err := bs.repo.UpdateVersionOfBanner(ctx, id, version)
 	return err
 }
+
+func (bs *BannerService) CountActiveBanners(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "BannerService.CountActiveBanners")
+	defer span.End()
+
+	return bs.repo.CountActiveBanners(ctx)
+}
+
+func (bs *BannerService) SearchBanners(ctx context.Context,
+	search models.BannerSearch) ([]models.BannerSearchResult, error) {
+	ctx, span := tracing.Start(ctx, "BannerService.SearchBanners")
+	defer span.End()
+
+	return bs.repo.SearchBanners(ctx, search)
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/tracing"
+)
+
+// GetSnapshot reads every banner from the primary, the version is the time the snapshot was taken.
+func (bs *BannerService) GetSnapshot(ctx context.Context) (models.BannerSnapshot, error) {
+	ctx, span := tracing.Start(ctx, "BannerService.GetSnapshot")
+	defer span.End()
+
+	now := time.Now().UTC()
+	snap := models.BannerSnapshot{Version: now.UnixNano(), GeneratedAt: now, Banners: []models.SnapshotBanner{}}
+	err := bs.repo.ExportBanners(ctx, false, func(r models.BannerRecord) error {
+		if !r.IsActive {
+			return nil
+		}
+		for _, tagID := range r.TagIDs {
+			snap.Banners = append(snap.Banners, models.SnapshotBanner{
+				TagID:     tagID,
+				FeatureID: r.FeatureID,
+				Content:   r.Content,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return models.BannerSnapshot{}, err
+	}
+
+	sort.Slice(snap.Banners, func(i, j int) bool {
+		a, b := snap.Banners[i], snap.Banners[j]
+		if a.TagID != b.TagID {
+			return a.TagID < b.TagID
+		}
+		return a.FeatureID < b.FeatureID
+	})
+	return snap, nil
+}
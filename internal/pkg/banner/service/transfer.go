@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/tracing"
+)
+
+var (
+	errRecordContent = errors.New("content must be a JSON value")
+	errRecordTags    = errors.New("tag_ids must not be empty")
+	errRecordFeature = errors.New("feature_id is required")
+	errRecordVersion = errors.New("versions must have a JSON content")
+)
+
+func (bs *BannerService) ExportBanners(ctx context.Context, withVersions bool,
+	fn func(models.BannerRecord) error) error {
+	ctx, span := tracing.Start(ctx, "BannerService.ExportBanners")
+	defer span.End()
+
+	return bs.repo.ExportBanners(ctx, withVersions, fn)
+}
+
+// ImportBanners reports invalid lines without sending them to the repository, together with the lines it rejects.
+func (bs *BannerService) ImportBanners(ctx context.Context, lines []models.ImportLine,
+	opts models.ImportOptions) (models.ImportReport, error) {
+	ctx, span := tracing.Start(ctx, "BannerService.ImportBanners")
+	defer span.End()
+
+	valid := make([]models.ImportLine, 0, len(lines))
+	var invalid []models.ImportError
+	for _, l := range lines {
+		err := l.Err
+		if err == nil {
+			err = validateRecord(l.Record)
+		}
+		if err != nil {
+			invalid = append(invalid, models.ImportError{Line: l.Line, Error: err.Error()})
+			continue
+		}
+		valid = append(valid, l)
+	}
+
+	// An atomic import with invalid lines will not commit, but the rest is still checked against the database.
+	requested := opts.DryRun
+	if opts.Atomic && len(invalid) > 0 {
+		opts.DryRun = true
+	}
+
+	report, err := bs.repo.ImportBanners(ctx, valid, opts)
+	if err != nil {
+		return report, err
+	}
+
+	report.DryRun = requested
+	report.Total = len(lines)
+	report.Failed += len(invalid)
+	report.Errors = append(report.Errors, invalid...)
+	sort.Slice(report.Errors, func(i, j int) bool { return report.Errors[i].Line < report.Errors[j].Line })
+
+	return report, nil
+}
+
+func validateRecord(r models.BannerRecord) error {
+	switch {
+	case len(r.Content) == 0 || !json.Valid(r.Content):
+		return errRecordContent
+	case len(r.TagIDs) == 0:
+		return errRecordTags
+	case r.FeatureID <= 0:
+		return errRecordFeature
+	}
+
+	for _, v := range r.Versions {
+		if len(v.Content) == 0 || !json.Valid(v.Content) {
+			return errRecordVersion
+		}
+	}
+
+	return nil
+}
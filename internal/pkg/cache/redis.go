@@ -2,28 +2,74 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	"banner-service/internal/pkg/tracing"
 )
 
+type Recorder interface {
+	ObserveCache(operation, result string)
+}
+
 type RedisClient struct {
 	client   *redis.Client
-	cacheTTL time.Duration
+	cacheTTL atomic.Int64
+	recorder Recorder
+}
+
+// NewRedisClient caches nothing when client is nil.
+func NewRedisClient(client *redis.Client, ttl time.Duration, recorder Recorder) *RedisClient {
+	rc := &RedisClient{client: client, recorder: recorder}
+	rc.SetTTL(ttl)
+	return rc
 }
 
-func NewRedisClient(client *redis.Client, ttl time.Duration) *RedisClient {
-	return &RedisClient{client: client, cacheTTL: ttl}
+func (rc *RedisClient) SetTTL(ttl time.Duration) {
+	rc.cacheTTL.Store(int64(ttl))
 }
 
 func (rc *RedisClient) Get(ctx context.Context, key string) ([]byte, bool) {
+	if rc.client == nil {
+		return nil, false
+	}
+
+	ctx, span := tracing.Start(ctx, "RedisClient.Get")
+	defer span.End()
+
 	value, err := rc.client.Get(ctx, key).Bytes()
 	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			rc.observe("get", "miss")
+		} else {
+			rc.observe("get", "error")
+		}
 		return nil, false
 	}
+	rc.observe("get", "hit")
 	return value, true
 }
 
-func (rc *RedisClient) Set(key string, value []byte) {
-	rc.client.Set(context.Background(), key, value, rc.cacheTTL)
+func (rc *RedisClient) Set(ctx context.Context, key string, value []byte) {
+	if rc.client == nil {
+		return
+	}
+
+	ctx, span := tracing.Start(context.WithoutCancel(ctx), "RedisClient.Set")
+	defer span.End()
+
+	if err := rc.client.Set(ctx, key, value, time.Duration(rc.cacheTTL.Load())).Err(); err != nil {
+		rc.observe("set", "error")
+		return
+	}
+	rc.observe("set", "ok")
+}
+
+func (rc *RedisClient) observe(operation, result string) {
+	if rc.recorder != nil {
+		rc.recorder.ObserveCache(operation, result)
+	}
 }
@@ -0,0 +1,284 @@
+// Package client is a Go client for the banner service HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"banner-service/internal/models"
+)
+
+const tokenCookie = "AccessToken"
+
+var ErrNoToken = errors.New("sign in did not return a token")
+
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("api returned %d %s", e.Status, http.StatusText(e.Status))
+	}
+	return fmt.Sprintf("api returned %d %s: %s", e.Status, http.StatusText(e.Status), e.Message)
+}
+
+type Client struct {
+	baseURL string
+	http    *http.Client
+	token   string
+}
+
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), http: httpClient}
+}
+
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+func (c *Client) Token() string {
+	return c.token
+}
+
+func (c *Client) SignIn(ctx context.Context, login, password string) error {
+	resp, err := c.do(ctx, http.MethodPost, "/api/sign_in", nil, models.User{Login: login, Password: password})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == tokenCookie {
+			c.token = cookie.Value
+			return nil
+		}
+	}
+	return ErrNoToken
+}
+
+func (c *Client) SignUp(ctx context.Context, u models.User) error {
+	return c.call(ctx, http.MethodPost, "/api/sign_up", nil, u, nil)
+}
+
+type BannerFilter struct {
+	TagID     int
+	FeatureID int
+	Limit     int
+	Offset    int
+	// Sort is banner_id, created_at or updated_at.
+	Sort   string
+	Desc   bool
+	Cursor string
+}
+
+func (f BannerFilter) query() url.Values {
+	q := url.Values{}
+	params := map[string]int{"tag_id": f.TagID, "feature_id": f.FeatureID, "limit": f.Limit, "offset": f.Offset}
+	for name, v := range params {
+		if v != 0 {
+			q.Set(name, strconv.Itoa(v))
+		}
+	}
+	if f.Sort != "" {
+		q.Set("sort", f.Sort)
+	}
+	if f.Desc {
+		q.Set("order", "desc")
+	}
+	if f.Cursor != "" {
+		q.Set("cursor", f.Cursor)
+	}
+	return q
+}
+
+type BannerList struct {
+	Banners []models.Banner
+	Total   int
+	// NextCursor is empty on the last page.
+	NextCursor string
+}
+
+func (c *Client) ListBanners(ctx context.Context, filter BannerFilter) (BannerList, error) {
+	var list BannerList
+	resp, err := c.do(ctx, http.MethodGet, "/api/banner", filter.query(), nil)
+	if err != nil {
+		return list, err
+	}
+	defer resp.Body.Close()
+
+	list.Total, _ = strconv.Atoi(resp.Header.Get("X-Total-Count"))
+	list.NextCursor = resp.Header.Get("X-Next-Cursor")
+	err = json.NewDecoder(resp.Body).Decode(&list.Banners)
+	return list, err
+}
+
+func (c *Client) UserBanner(ctx context.Context, tagID, featureID int, useLastRevision bool) (json.RawMessage, error) {
+	q := url.Values{}
+	q.Set("tag_id", strconv.Itoa(tagID))
+	q.Set("feature_id", strconv.Itoa(featureID))
+	q.Set("use_last_revision", strconv.FormatBool(useLastRevision))
+
+	var content json.RawMessage
+	err := c.call(ctx, http.MethodGet, "/api/user_banner", q, nil, &content)
+	return content, err
+}
+
+func (c *Client) CreateBanner(ctx context.Context, payload json.RawMessage) (int, error) {
+	var created struct {
+		BannerID int `json:"banner_id"`
+	}
+	err := c.call(ctx, http.MethodPost, "/api/banner", nil, payload, &created)
+	return created.BannerID, err
+}
+
+func (c *Client) UpdateBanner(ctx context.Context, id int, payload json.RawMessage) error {
+	return c.call(ctx, http.MethodPatch, "/api/banner/"+strconv.Itoa(id), nil, payload, nil)
+}
+
+func (c *Client) DeleteBanner(ctx context.Context, id int) error {
+	return c.call(ctx, http.MethodDelete, "/api/banner/"+strconv.Itoa(id), nil, nil, nil)
+}
+
+func (c *Client) BannerVersions(ctx context.Context, id int) (models.Versions, error) {
+	var versions models.Versions
+	err := c.call(ctx, http.MethodGet, "/api/banner/"+strconv.Itoa(id), nil, nil, &versions)
+	return versions, err
+}
+
+func (c *Client) ChangeBannerVersion(ctx context.Context, id, version int) error {
+	body := struct {
+		Version int `json:"version"`
+	}{version}
+	return c.call(ctx, http.MethodPut, "/api/banner/"+strconv.Itoa(id), nil, body, nil)
+}
+
+// ExportBanners copies the export in the format, jsonl or csv, to w.
+func (c *Client) ExportBanners(ctx context.Context, format string, withVersions bool, w io.Writer) error {
+	query := url.Values{"format": {format}, "versions": {strconv.FormatBool(withVersions)}}
+	resp, err := c.do(ctx, http.MethodGet, "/api/banner/export", query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+type ImportOptions struct {
+	Format    string
+	DryRun    bool
+	Chunked   bool
+	ChunkSize int
+}
+
+// ImportBanners returns the report also when some lines failed, the error is nil in that case.
+func (c *Client) ImportBanners(ctx context.Context, r io.Reader, opts ImportOptions) (models.ImportReport, error) {
+	query := url.Values{"format": {opts.Format}, "dry_run": {strconv.FormatBool(opts.DryRun)}}
+	if opts.Chunked {
+		query.Set("mode", "chunked")
+	}
+	if opts.ChunkSize > 0 {
+		query.Set("chunk_size", strconv.Itoa(opts.ChunkSize))
+	}
+
+	var report models.ImportReport
+	resp, err := c.send(ctx, http.MethodPost, "/api/banner/import", query, "", r)
+	if err != nil {
+		return report, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnprocessableEntity {
+		return report, apiError(resp)
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(&report)
+	return report, err
+}
+
+func (c *Client) call(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	resp, err := c.do(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values,
+	body interface{}) (*http.Response, error) {
+	var (
+		reader      io.Reader
+		contentType string
+	)
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader, contentType = bytes.NewReader(payload), "application/json"
+	}
+
+	resp, err := c.send(ctx, method, path, query, contentType, reader)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, apiError(resp)
+	}
+
+	return resp, nil
+}
+
+// send returns the response whatever its status is.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, contentType string,
+	body io.Reader) (*http.Response, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.AddCookie(&http.Cookie{Name: tokenCookie, Value: c.token})
+	}
+
+	return c.http.Do(req)
+}
+
+func apiError(resp *http.Response) error {
+	defer resp.Body.Close()
+	apiErr := &APIError{Status: resp.StatusCode}
+
+	var errResp struct {
+		Error string `json:"error"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if json.Unmarshal(raw, &errResp) == nil {
+		apiErr.Message = errResp.Error
+	}
+	return apiErr
+}
@@ -1,54 +1,279 @@
 package config
 
 import (
-	"log"
+	"errors"
+	"fmt"
 	"os"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
+
+	"banner-service/internal/pkg/ratelimit"
 )
 
 type Config struct {
 	HTTPServerConfig `yaml:"http_server"`
+	StorageConfig    `yaml:"storage"`
 	PostgresConfig   `yaml:"postgres"`
 	RedisConfig      `yaml:"redis"`
+	SignInConfig     `yaml:"sign_in"`
+	RateLimitConfig  `yaml:"rate_limit"`
+	OIDCConfig       `yaml:"oidc"`
+	TracingConfig    `yaml:"tracing"`
+	LoggingConfig    `yaml:"logging"`
+	CORSConfig       `yaml:"cors"`
+	SnapshotConfig   `yaml:"snapshot"`
+	EdgeConfig       `yaml:"edge"`
 }
 
 type HTTPServerConfig struct {
-	Address           string        `yaml:"address" yaml-default:"localhost:8080"`
-	Timeout           time.Duration `yaml:"timeout" yaml-default:"4s"`
-	IDleTimeout       time.Duration `yaml:"idleTimeout" yaml-default:"60s"`
-	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout" yaml-defualt:"10s"`
-	JWTSecret         string        `yaml:"JWTSecret"`
-	JWTTTL            time.Duration `yaml:"JWTTTL" yaml-defualt:"6h"`
+	// Mode is api, or edge for a read-only node that serves /api/user_banner from snapshots.
+	Mode              string        `yaml:"mode" env:"SERVER_MODE" env-default:"api"`
+	Address           string        `yaml:"address" env:"HTTP_ADDRESS" env-default:"localhost:8080"`
+	Timeout           time.Duration `yaml:"timeout" env:"HTTP_TIMEOUT" env-default:"4s"`
+	IDleTimeout       time.Duration `yaml:"idleTimeout" env:"HTTP_IDLE_TIMEOUT" env-default:"60s"`
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout" env:"HTTP_READ_HEADER_TIMEOUT" env-default:"10s"`
+	JWTSecret         string        `yaml:"JWTSecret" env:"JWT_SECRET"`
+	JWTTTL            time.Duration `yaml:"JWTTTL" env:"JWT_TTL" env-default:"6h"`
+	JWTKeys           []JWTKey      `yaml:"JWTKeys" env:"JWT_KEYS"`
+	JWTIssuer         string        `yaml:"JWTIssuer" env:"JWT_ISSUER" env-default:"banner-service"`
+	JWTAudience       string        `yaml:"JWTAudience" env:"JWT_AUDIENCE" env-default:"banner-service"`
+	JWTLeeway         time.Duration `yaml:"JWTLeeway" env:"JWT_LEEWAY" env-default:"30s"`
+	HealthTimeout     time.Duration `yaml:"healthTimeout" env:"HEALTH_TIMEOUT" env-default:"2s"`
+	ShutdownDelay     time.Duration `yaml:"shutdownDelay" env:"SHUTDOWN_DELAY" env-default:"5s"`
+	ReloadInterval    time.Duration `yaml:"reloadInterval" env:"CONFIG_RELOAD_INTERVAL" env-default:"5s"`
+}
+
+type JWTKey struct {
+	ID             string    `yaml:"id"`
+	PrivateKeyFile string    `yaml:"privateKeyFile"`
+	ActiveFrom     time.Time `yaml:"activeFrom"`
+	ExpiresAt      time.Time `yaml:"expiresAt"`
+}
+
+// SetValue parses "id;privateKeyFile;activeFrom;expiresAt" with RFC 3339 times; the times may be left empty.
+func (k *JWTKey) SetValue(s string) error {
+	parts := strings.Split(s, ";")
+	if len(parts) != 4 {
+		return fmt.Errorf("invalid jwt key %q, expected id;privateKeyFile;activeFrom;expiresAt", s)
+	}
+
+	key := JWTKey{ID: parts[0], PrivateKeyFile: parts[1]}
+	for i, t := range []*time.Time{&key.ActiveFrom, &key.ExpiresAt} {
+		if parts[i+2] == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, parts[i+2])
+		if err != nil {
+			return fmt.Errorf("invalid jwt key %s time: %w", key.ID, err)
+		}
+		*t = parsed
+	}
+
+	*k = key
+	return nil
 }
 
 type RedisConfig struct {
-	RedisAddr     string        `yaml:"address"`
-	RedisPassword string        `yaml:"cachePas"`
-	RedisTTL      time.Duration `yaml:"cacheTTL"`
+	RedisAddr     string        `yaml:"address" env:"REDIS_ADDRESS"`
+	RedisPassword string        `yaml:"cachePass" env:"REDIS_PASSWORD"`
+	RedisTTL      time.Duration `yaml:"cacheTTL" env:"REDIS_TTL" env-default:"5m" reload:"true"`
+}
+
+// StorageConfig selects where banners and users are kept. The memory and file backends start with tags and
+// features 1 to StorageTags and StorageFeatures and, when a password is set, an admin user. Memory loses
+// everything on exit, file keeps it in StorageDir. Neither uses Redis.
+type StorageConfig struct {
+	StorageBackend       string `yaml:"backend" env:"STORAGE_BACKEND" env-default:"postgres"`
+	StorageTags          int    `yaml:"tags" env:"STORAGE_TAGS" env-default:"1000"`
+	StorageFeatures      int    `yaml:"features" env:"STORAGE_FEATURES" env-default:"1000"`
+	StorageAdminLogin    string `yaml:"adminLogin" env:"STORAGE_ADMIN_LOGIN" env-default:"admin"`
+	StorageAdminPassword string `yaml:"adminPassword" env:"STORAGE_ADMIN_PASSWORD"`
+	StorageDir           string `yaml:"dir" env:"STORAGE_DIR" env-default:"data"`
+	StorageCompactEvery  int    `yaml:"compactEvery" env:"STORAGE_COMPACT_EVERY" env-default:"1000"`
+}
+
+// Standalone tells whether the service runs without Postgres and Redis.
+func (c *StorageConfig) Standalone() bool {
+	return c.StorageBackend != "postgres"
 }
 
 type PostgresConfig struct {
-	DBName string `yaml:"dbName"`
-	DBPass string `yaml:"dbPass"`
-	DBHost string `yaml:"dbHost"`
-	DBPort int    `yaml:"dbPort"`
-	DBUser string `yaml:"dbUser"`
+	DBName string `yaml:"dbName" env:"DB_NAME"`
+	DBPass string `yaml:"dbPass" env:"DB_PASSWORD"`
+	DBHost string `yaml:"dbHost" env:"DB_HOST"`
+	DBPort int    `yaml:"dbPort" env:"DB_PORT" env-default:"5432"`
+	DBUser string `yaml:"dbUser" env:"DB_USER"`
+	// DBMigrate applies pending migrations on start.
+	DBMigrate bool `yaml:"migrate" env:"DB_MIGRATE"`
+	// DBMinConns connections are kept open even when idle.
+	DBMaxConns          int32         `yaml:"maxConns" env:"DB_MAX_CONNS" env-default:"20"`
+	DBMinConns          int32         `yaml:"minConns" env:"DB_MIN_CONNS" env-default:"2"`
+	DBMaxConnLifetime   time.Duration `yaml:"maxConnLifetime" env:"DB_MAX_CONN_LIFETIME" env-default:"1h"`
+	DBMaxConnIdleTime   time.Duration `yaml:"maxConnIdleTime" env:"DB_MAX_CONN_IDLE_TIME" env-default:"30m"`
+	DBHealthCheckPeriod time.Duration `yaml:"healthCheckPeriod" env:"DB_HEALTH_CHECK_PERIOD" env-default:"1m"`
+	// DBReplicas are host:port addresses of read replicas, they share the name and credentials of the primary.
+	DBReplicas           []string      `yaml:"replicas" env:"DB_REPLICAS"`
+	DBReplicaCheckPeriod time.Duration `yaml:"replicaCheckPeriod" env:"DB_REPLICA_CHECK_PERIOD" env-default:"5s"`
 }
 
-func Load(filename string) (*Config, error) {
-	var cfg Config
+func (c PostgresConfig) DSN() string {
+	return fmt.Sprintf("postgres://%v:%v@%v:%v/%v?sslmode=disable", c.DBUser, c.DBPass, c.DBHost, c.DBPort, c.DBName)
+}
+
+// ReplicaDSN is DSN with the host and port of the replica at address.
+func (c PostgresConfig) ReplicaDSN(address string) string {
+	return fmt.Sprintf("postgres://%v:%v@%v/%v?sslmode=disable", c.DBUser, c.DBPass, address, c.DBName)
+}
+
+type SignInConfig struct {
+	SignInLoginAttempts    int           `yaml:"loginAttempts" env:"SIGN_IN_LOGIN_ATTEMPTS" env-default:"5" reload:"true"`
+	SignInIPAttempts       int           `yaml:"ipAttempts" env:"SIGN_IN_IP_ATTEMPTS" env-default:"20" reload:"true"`
+	SignInWindow           time.Duration `yaml:"window" env:"SIGN_IN_WINDOW" env-default:"1m" reload:"true"`
+	SignInLockoutThreshold int           `yaml:"lockoutThreshold" env:"SIGN_IN_LOCKOUT_THRESHOLD" env-default:"10" reload:"true"`
+	SignInLockoutDuration  time.Duration `yaml:"lockoutDuration" env:"SIGN_IN_LOCKOUT_DURATION" env-default:"15m" reload:"true"`
+}
+
+type RateLimitConfig struct {
+	RateLimitDefault ratelimit.Limit            `yaml:"default" env-prefix:"RATE_LIMIT_DEFAULT_" reload:"true"`
+	RateLimitRoutes  map[string]ratelimit.Limit `yaml:"routes" env:"RATE_LIMIT_ROUTES" reload:"true"`
+}
+
+type OIDCConfig struct {
+	OIDCEnabled      bool           `yaml:"enabled" env:"OIDC_ENABLED"`
+	OIDCIssuer       string         `yaml:"issuer" env:"OIDC_ISSUER"`
+	OIDCClientID     string         `yaml:"clientID" env:"OIDC_CLIENT_ID"`
+	OIDCClientSecret string         `yaml:"clientSecret" env:"OIDC_CLIENT_SECRET"`
+	OIDCRedirectURL  string         `yaml:"redirectURL" env:"OIDC_REDIRECT_URL"`
+	OIDCScopes       []string       `yaml:"scopes" env:"OIDC_SCOPES"`
+	OIDCGroupsClaim  string         `yaml:"groupsClaim" env:"OIDC_GROUPS_CLAIM" env-default:"groups"`
+	OIDCAdminGroups  []string       `yaml:"adminGroups" env:"OIDC_ADMIN_GROUPS"`
+	OIDCTagGroups    map[string]int `yaml:"tagGroups" env:"OIDC_TAG_GROUPS"`
+}
+
+type TracingConfig struct {
+	TracingExporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	TracingEndpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+	TracingFile        string  `yaml:"file" env:"TRACING_FILE" env-default:"traces.json"`
+	TracingServiceName string  `yaml:"serviceName" env:"TRACING_SERVICE_NAME" env-default:"banner-service"`
+	TracingSampleRatio float64 `yaml:"sampleRatio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+}
+
+type LoggingConfig struct {
+	LogLevel  string `yaml:"level" env:"LOG_LEVEL" env-default:"info" reload:"true"`
+	LogFormat string `yaml:"format" env:"LOG_FORMAT" env-default:"text" reload:"true"`
+}
+
+type CORSConfig struct {
+	CORSAllowedOrigins []string      `yaml:"allowedOrigins" env:"CORS_ALLOWED_ORIGINS" reload:"true"`
+	CORSAllowedMethods []string      `yaml:"allowedMethods" env:"CORS_ALLOWED_METHODS" env-default:"GET,POST,PATCH,PUT,DELETE" reload:"true"`
+	CORSAllowedHeaders []string      `yaml:"allowedHeaders" env:"CORS_ALLOWED_HEADERS" env-default:"Authorization,Content-Type,X-Request-ID,If-None-Match" reload:"true"`
+	CORSMaxAge         time.Duration `yaml:"maxAge" env:"CORS_MAX_AGE" env-default:"10m" reload:"true"`
+}
+
+// SnapshotConfig enables GET /api/banner/snapshot, which signs the snapshots with an ed25519 key.
+type SnapshotConfig struct {
+	SnapshotKeyFile string `yaml:"signingKeyFile" env:"SNAPSHOT_SIGNING_KEY_FILE"`
+}
+
+// EdgeConfig is for the edge mode. EdgeSource is a file path or a URL; with a login the node signs in to the
+// service the URL belongs to.
+type EdgeConfig struct {
+	EdgeSource        string        `yaml:"source" env:"EDGE_SOURCE"`
+	EdgePublicKeyFile string        `yaml:"publicKeyFile" env:"EDGE_PUBLIC_KEY_FILE"`
+	EdgePollInterval  time.Duration `yaml:"pollInterval" env:"EDGE_POLL_INTERVAL" env-default:"30s"`
+	EdgeLogin         string        `yaml:"login" env:"EDGE_LOGIN"`
+	EdgePassword      string        `yaml:"password" env:"EDGE_PASSWORD"`
+}
+
+// Load reads filename, then environment variables, then <VAR>_FILE secrets, and validates the result.
+// A missing file is an error only when required is set, otherwise configuration comes from the environment alone.
+func Load(filename string, required bool) (*Config, error) {
+	cfg, err := read(filename, required)
+	if err != nil {
+		return nil, err
+	}
 
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		log.Printf("config file does not exist: %s", filename)
+	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
 
-	if err := cleanenv.ReadConfig(filename, &cfg); err != nil {
-		log.Printf("cannot read %s: %v", filename, err)
+	return cfg, nil
+}
+
+// LoadPostgres validates only the postgres section, for tools that go to the database and nothing else.
+func LoadPostgres(filename string, required bool) (*Config, error) {
+	cfg, err := read(filename, required)
+	if err != nil {
+		return nil, err
+	}
+
+	var p problems
+	cfg.validatePostgres(&p)
+	if len(p) > 0 {
+		return nil, &ValidationError{Problems: p}
+	}
+
+	return cfg, nil
+}
+
+func read(filename string, required bool) (*Config, error) {
+	var cfg Config
+
+	_, err := os.Stat(filename)
+	switch {
+	case err == nil:
+		if err := cleanenv.ReadConfig(filename, &cfg); err != nil {
+			return nil, fmt.Errorf("cannot read config %s: %w", filename, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !required:
+		if err := cleanenv.ReadEnv(&cfg); err != nil {
+			return nil, fmt.Errorf("cannot read config from environment: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("cannot read config %s: %w", filename, err)
+	}
+
+	if err := readSecretFiles(reflect.ValueOf(&cfg).Elem(), ""); err != nil {
 		return nil, err
 	}
 
 	return &cfg, nil
 }
+
+// readSecretFiles fills every string field whose <VAR>_FILE is set from that file, so secrets can be mounted.
+func readSecretFiles(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		env, ok := f.Tag.Lookup("env")
+		if !ok {
+			if f.Type.Kind() == reflect.Struct {
+				if err := readSecretFiles(v.Field(i), prefix+f.Tag.Get("env-prefix")); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		name := prefix + env
+		path, ok := os.LookupEnv(name + "_FILE")
+		if !ok || f.Type.Kind() != reflect.String {
+			continue
+		}
+		if _, ok := os.LookupEnv(name); ok {
+			return fmt.Errorf("both %s and %s_FILE are set", name, name)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("cannot read %s_FILE: %w", name, err)
+		}
+		v.Field(i).SetString(strings.TrimRight(string(content), "\r\n"))
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type Change struct {
+	Field      string
+	Old        interface{}
+	New        interface{}
+	Reloadable bool
+	secret     bool
+}
+
+func (c Change) String() string {
+	if c.secret {
+		return c.Field + ": <redacted>"
+	}
+	return fmt.Sprintf("%s: %v -> %v", c.Field, c.Old, c.New)
+}
+
+// Diff lists the fields that differ between two configs; fields tagged reload:"true" can be applied without restart.
+func Diff(old, updated *Config) []Change {
+	return diff(reflect.ValueOf(old).Elem(), reflect.ValueOf(updated).Elem(), "")
+}
+
+func diff(old, updated reflect.Value, section string) []Change {
+	var changes []Change
+
+	t := old.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			changes = append(changes, diff(old.Field(i), updated.Field(i), f.Tag.Get("yaml"))...)
+			continue
+		}
+
+		o, n := old.Field(i).Interface(), updated.Field(i).Interface()
+		if reflect.DeepEqual(o, n) {
+			continue
+		}
+
+		env := f.Tag.Get("env")
+		changes = append(changes, Change{
+			Field:      section + "." + f.Tag.Get("yaml"),
+			Old:        o,
+			New:        n,
+			Reloadable: f.Tag.Get("reload") == "true",
+			secret:     strings.HasSuffix(env, "SECRET") || strings.HasSuffix(env, "PASSWORD"),
+		})
+	}
+
+	return changes
+}
+
+// Watch calls onChange whenever the modification time or size of filename changes, until ctx is done.
+func Watch(ctx context.Context, filename string, interval time.Duration, onChange func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last, _ := os.Stat(filename)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := os.Stat(filename)
+		if err != nil {
+			continue
+		}
+		if last == nil || !current.ModTime().Equal(last.ModTime()) || current.Size() != last.Size() {
+			last = current
+			onChange()
+		}
+	}
+}
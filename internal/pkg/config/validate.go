@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+type problems []string
+
+func (p *problems) check(ok bool, format string, args ...interface{}) {
+	if !ok {
+		*p = append(*p, fmt.Sprintf(format, args...))
+	}
+}
+
+func (c *Config) Validate() error {
+	var p problems
+	c.validateHTTPServer(&p)
+	if c.Mode == "edge" {
+		c.validateEdge(&p)
+	} else {
+		c.validateStorage(&p)
+	}
+	c.validateAuth(&p)
+	c.validateObservability(&p)
+
+	for i, o := range c.CORSAllowedOrigins {
+		p.check(o != "", "cors.allowedOrigins[%d] (CORS_ALLOWED_ORIGINS) must not be empty", i)
+	}
+	p.check(c.CORSMaxAge >= 0, "cors.maxAge (CORS_MAX_AGE) must not be negative")
+
+	if len(p) > 0 {
+		return &ValidationError{Problems: p}
+	}
+
+	return nil
+}
+
+func (c *Config) validateHTTPServer(p *problems) {
+	check := p.check
+
+	check(c.Mode == "api" || c.Mode == "edge", "http_server.mode (SERVER_MODE) must be api or edge, got %q", c.Mode)
+	_, _, err := net.SplitHostPort(c.Address)
+	check(err == nil, "http_server.address (HTTP_ADDRESS) must be host:port, got %q", c.Address)
+	check(c.Timeout > 0, "http_server.timeout (HTTP_TIMEOUT) must be positive")
+	check(c.IDleTimeout > 0, "http_server.idleTimeout (HTTP_IDLE_TIMEOUT) must be positive")
+	check(c.ReadHeaderTimeout > 0, "http_server.readHeaderTimeout (HTTP_READ_HEADER_TIMEOUT) must be positive")
+	check(c.JWTSecret != "" || len(c.JWTKeys) > 0,
+		"http_server.JWTSecret (JWT_SECRET) or http_server.JWTKeys (JWT_KEYS) is required")
+	check(c.JWTSecret == "" || len(c.JWTSecret) >= 32,
+		"http_server.JWTSecret (JWT_SECRET) must be at least 32 characters")
+	check(c.JWTTTL > 0, "http_server.JWTTTL (JWT_TTL) must be positive")
+	check(c.JWTLeeway >= 0, "http_server.JWTLeeway (JWT_LEEWAY) must not be negative")
+	for i, k := range c.JWTKeys {
+		check(k.ID != "", "http_server.JWTKeys[%d].id is required", i)
+		check(k.PrivateKeyFile != "", "http_server.JWTKeys[%d].privateKeyFile is required", i)
+		check(k.ExpiresAt.IsZero() || k.ExpiresAt.After(k.ActiveFrom),
+			"http_server.JWTKeys[%d].expiresAt must be after activeFrom", i)
+	}
+	check(c.HealthTimeout > 0, "http_server.healthTimeout (HEALTH_TIMEOUT) must be positive")
+	check(c.ShutdownDelay >= 0, "http_server.shutdownDelay (SHUTDOWN_DELAY) must not be negative")
+	check(c.ReloadInterval >= 0, "http_server.reloadInterval (CONFIG_RELOAD_INTERVAL) must not be negative")
+}
+
+func (c *Config) validateEdge(p *problems) {
+	check := p.check
+
+	check(c.EdgeSource != "", "edge.source (EDGE_SOURCE) is required")
+	check(c.EdgePublicKeyFile != "", "edge.publicKeyFile (EDGE_PUBLIC_KEY_FILE) is required")
+	check(c.EdgePollInterval > 0, "edge.pollInterval (EDGE_POLL_INTERVAL) must be positive")
+	check(c.EdgeLogin == "" || strings.HasPrefix(c.EdgeSource, "http"),
+		"edge.login (EDGE_LOGIN) needs an http(s) edge.source (EDGE_SOURCE)")
+	check(c.RedisTTL > 0, "redis.cacheTTL (REDIS_TTL) must be positive")
+}
+
+func (c *Config) validateStorage(p *problems) {
+	check := p.check
+
+	switch c.StorageBackend {
+	case "postgres":
+		c.validatePostgres(p)
+	case "file":
+		check(c.StorageDir != "", "storage.dir (STORAGE_DIR) is required")
+		check(c.StorageCompactEvery > 0, "storage.compactEvery (STORAGE_COMPACT_EVERY) must be positive")
+		fallthrough
+	case "memory":
+		check(c.StorageTags >= 0, "storage.tags (STORAGE_TAGS) must not be negative")
+		check(c.StorageFeatures >= 0, "storage.features (STORAGE_FEATURES) must not be negative")
+		check(c.StorageAdminPassword == "" || (c.StorageAdminLogin != "" && len(c.StorageAdminPassword) <= 32),
+			"storage.adminPassword (STORAGE_ADMIN_PASSWORD) must be at most 32 characters and needs a login")
+	default:
+		check(false, "storage.backend (STORAGE_BACKEND) must be postgres, memory or file, got %q",
+			c.StorageBackend)
+	}
+
+	check(c.Standalone() || c.RedisAddr != "", "redis.address (REDIS_ADDRESS) is required")
+	check(c.RedisTTL > 0, "redis.cacheTTL (REDIS_TTL) must be positive")
+}
+
+func (c *Config) validatePostgres(p *problems) {
+	check := p.check
+
+	check(c.DBHost != "", "postgres.dbHost (DB_HOST) is required")
+	check(c.DBPort > 0 && c.DBPort < 65536, "postgres.dbPort (DB_PORT) must be between 1 and 65535, got %d", c.DBPort)
+	check(c.DBName != "", "postgres.dbName (DB_NAME) is required")
+	check(c.DBUser != "", "postgres.dbUser (DB_USER) is required")
+	check(c.DBMaxConns > 0, "postgres.maxConns (DB_MAX_CONNS) must be positive")
+	check(c.DBMinConns >= 0 && c.DBMinConns <= c.DBMaxConns,
+		"postgres.minConns (DB_MIN_CONNS) must be between 0 and postgres.maxConns")
+	check(c.DBMaxConnLifetime > 0, "postgres.maxConnLifetime (DB_MAX_CONN_LIFETIME) must be positive")
+	check(c.DBMaxConnIdleTime > 0, "postgres.maxConnIdleTime (DB_MAX_CONN_IDLE_TIME) must be positive")
+	check(c.DBHealthCheckPeriod > 0, "postgres.healthCheckPeriod (DB_HEALTH_CHECK_PERIOD) must be positive")
+	for i, r := range c.DBReplicas {
+		_, _, err := net.SplitHostPort(r)
+		check(err == nil, "postgres.replicas[%d] (DB_REPLICAS) must be host:port, got %q", i, r)
+	}
+	check(c.DBReplicaCheckPeriod > 0, "postgres.replicaCheckPeriod (DB_REPLICA_CHECK_PERIOD) must be positive")
+}
+
+func (c *Config) validateAuth(p *problems) {
+	check := p.check
+
+	check(c.SignInLoginAttempts > 0, "sign_in.loginAttempts (SIGN_IN_LOGIN_ATTEMPTS) must be positive")
+	check(c.SignInIPAttempts > 0, "sign_in.ipAttempts (SIGN_IN_IP_ATTEMPTS) must be positive")
+	check(c.SignInWindow > 0, "sign_in.window (SIGN_IN_WINDOW) must be positive")
+	check(c.SignInLockoutThreshold > 0, "sign_in.lockoutThreshold (SIGN_IN_LOCKOUT_THRESHOLD) must be positive")
+	check(c.SignInLockoutDuration > 0, "sign_in.lockoutDuration (SIGN_IN_LOCKOUT_DURATION) must be positive")
+
+	check(c.RateLimitDefault.Rate >= 0 && c.RateLimitDefault.Burst >= 0,
+		"rate_limit.default (RATE_LIMIT_DEFAULT_RATE, RATE_LIMIT_DEFAULT_BURST) must not be negative")
+	for route, l := range c.RateLimitRoutes {
+		check(l.Rate >= 0 && l.Burst >= 0, "rate_limit.routes.%s (RATE_LIMIT_ROUTES) must not be negative", route)
+	}
+
+	if c.OIDCEnabled {
+		check(c.OIDCIssuer != "", "oidc.issuer (OIDC_ISSUER) is required when oidc is enabled")
+		check(c.OIDCClientID != "", "oidc.clientID (OIDC_CLIENT_ID) is required when oidc is enabled")
+		check(c.OIDCRedirectURL != "", "oidc.redirectURL (OIDC_REDIRECT_URL) is required when oidc is enabled")
+	}
+}
+
+func (c *Config) validateObservability(p *problems) {
+	check := p.check
+
+	switch c.TracingExporter {
+	case "none", "stdout", "file":
+	case "otlp":
+		check(c.TracingEndpoint != "", "tracing.endpoint (TRACING_ENDPOINT) is required for the otlp exporter")
+	default:
+		check(false, "tracing.exporter (TRACING_EXPORTER) must be none, stdout, file or otlp, got %q", c.TracingExporter)
+	}
+	check(c.TracingSampleRatio >= 0 && c.TracingSampleRatio <= 1,
+		"tracing.sampleRatio (TRACING_SAMPLE_RATIO) must be between 0 and 1")
+
+	_, err := logrus.ParseLevel(c.LogLevel)
+	check(err == nil, "logging.level (LOG_LEVEL) is not a valid level: %q", c.LogLevel)
+	check(c.LogFormat == "text" || c.LogFormat == "json",
+		"logging.format (LOG_FORMAT) must be text or json, got %q", c.LogFormat)
+}
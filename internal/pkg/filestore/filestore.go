@@ -0,0 +1,255 @@
+// Package filestore keeps a memstore.Store in a directory: a snapshot of the whole store and a journal
+// of the changes committed after it, one JSON line each.
+package filestore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/pkg/memstore"
+)
+
+const (
+	snapshotFile = "snapshot.json"
+	journalFile  = "journal.jsonl"
+)
+
+// The sequence number of a change tells whether the snapshot already has it, the journal of a compaction
+// that stopped before truncating it is replayed on top of the new snapshot.
+type snapshot struct {
+	Seq   int64             `json:"seq"`
+	Store memstore.Snapshot `json:"store"`
+}
+
+type entry struct {
+	Seq    int64           `json:"seq"`
+	Change memstore.Change `json:"change"`
+}
+
+// Files writes every change of the store to the journal before it is committed and compacts the journal
+// into a new snapshot once it has compactEvery changes.
+type Files struct {
+	dir          string
+	store        *memstore.Store
+	compactEvery int
+	logger       *logrus.Logger
+
+	// journal, size, seq and pending are changed under the write lock of the store or, by a compaction
+	// that holds compacting, under its read lock.
+	journal    *os.File
+	size       int64
+	seq        int64
+	pending    int
+	compact    chan struct{}
+	compacting sync.Mutex
+	closed     sync.Once
+}
+
+// Open restores the store from dir, which is created when missing, and journals its changes from then on.
+func Open(dir string, store *memstore.Store, compactEvery int, logger *logrus.Logger) (*Files, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	f := &Files{
+		dir:          dir,
+		store:        store,
+		compactEvery: compactEvery,
+		logger:       logger,
+		compact:      make(chan struct{}, 1),
+	}
+	if err := f.restore(); err != nil {
+		return nil, err
+	}
+
+	journal, err := os.OpenFile(filepath.Join(dir, journalFile), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	f.journal = journal
+	if err = f.replay(); err != nil {
+		journal.Close()
+		return nil, err
+	}
+
+	store.SetJournal(f.write)
+	return f, nil
+}
+
+func (f *Files) restore() error {
+	data, err := os.ReadFile(filepath.Join(f.dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snap snapshot
+	if err = json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("%s: %w", snapshotFile, err)
+	}
+	f.store.Restore(snap.Store)
+	f.seq = snap.Seq
+	return nil
+}
+
+// replay applies the journal after the snapshot. A last line that is cut short was not committed, it is
+// dropped; a broken line before it means the journal is damaged.
+func (f *Files) replay() error {
+	r := bufio.NewReader(f.journal)
+	var offset int64
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(data)) > 0 {
+				f.logger.Warnf("%s: dropping the unfinished line %d", journalFile, line)
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var e entry
+		if err = json.Unmarshal(data, &e); err != nil {
+			return fmt.Errorf("%s: line %d: %w", journalFile, line, err)
+		}
+		offset += int64(len(data))
+		if e.Seq <= f.seq {
+			continue
+		}
+		f.store.Apply(e.Change)
+		f.seq = e.Seq
+		f.pending++
+	}
+
+	return f.truncate(offset)
+}
+
+func (f *Files) truncate(size int64) error {
+	if err := f.journal.Truncate(size); err != nil {
+		return err
+	}
+	if _, err := f.journal.Seek(size, io.SeekStart); err != nil {
+		return err
+	}
+	f.size = size
+	return nil
+}
+
+func (f *Files) write(c memstore.Change) error {
+	data, err := json.Marshal(entry{Seq: f.seq + 1, Change: c})
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	// A line that is not all written would break the ones after it.
+	if _, err = f.journal.Write(data); err != nil {
+		return errors.Join(err, f.truncate(f.size))
+	}
+	if err = f.journal.Sync(); err != nil {
+		return errors.Join(err, f.truncate(f.size))
+	}
+
+	f.size += int64(len(data))
+	f.seq++
+	f.pending++
+	if f.pending >= f.compactEvery {
+		select {
+		case f.compact <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Run compacts the journal in the background until ctx is done.
+func (f *Files) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-f.compact:
+			if err := f.Compact(); err != nil {
+				f.logger.Error("failed to compact the storage journal: ", err)
+			}
+		}
+	}
+}
+
+// Compact writes a new snapshot and empties the journal. Reads go on meanwhile, writes wait.
+func (f *Files) Compact() error {
+	f.compacting.Lock()
+	defer f.compacting.Unlock()
+
+	return f.store.Snapshot(func(s memstore.Snapshot) error {
+		if err := f.writeSnapshot(snapshot{Seq: f.seq, Store: s}); err != nil {
+			return err
+		}
+		if err := f.truncate(0); err != nil {
+			return err
+		}
+		f.pending = 0
+		return nil
+	})
+}
+
+// writeSnapshot replaces the snapshot with a rename, a crash leaves either the old one or the new one.
+func (f *Files) writeSnapshot(snap snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(f.dir, snapshotFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), filepath.Join(f.dir, snapshotFile)); err != nil {
+		return err
+	}
+
+	dir, err := os.Open(f.dir)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// Close compacts the journal so that the next start reads a single file, the store is not written after.
+func (f *Files) Close() error {
+	var err error
+	f.closed.Do(func() {
+		if err = f.Compact(); err != nil {
+			f.logger.Error("failed to compact the storage journal: ", err)
+		}
+		f.store.SetJournal(func(memstore.Change) error { return errors.New("storage is closed") })
+		err = f.journal.Close()
+	})
+	return err
+}
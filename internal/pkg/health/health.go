@@ -0,0 +1,126 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"banner-service/internal/utils/responser"
+)
+
+const (
+	StatusUp          = "up"
+	StatusDown        = "down"
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+	StatusShutdown    = "shutting_down"
+)
+
+// Check is a dependency probe. Optional checks degrade readiness instead of failing it.
+type Check struct {
+	Name     string
+	Ping     func(ctx context.Context) error
+	Optional bool
+}
+
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Optional  bool    `json:"optional,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type Checker struct {
+	checks   []Check
+	timeout  time.Duration
+	shutdown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+// Shutdown makes readiness fail so that traffic drains before the server stops.
+func (c *Checker) Shutdown() {
+	c.shutdown.Store(true)
+}
+
+func (c *Checker) Check(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(c.checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			res := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = res
+			if res.Status == StatusDown {
+				if !check.Optional {
+					report.Status = StatusUnavailable
+				} else if report.Status == StatusOK {
+					report.Status = StatusDegraded
+				}
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	if c.shutdown.Load() {
+		report.Status = StatusShutdown
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Ping(ctx)
+	res := CheckResult{
+		Status:    StatusUp,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Optional:  check.Optional,
+	}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+func (c *Checker) Live(w http.ResponseWriter, _ *http.Request) {
+	resp, _ := json.Marshal(Report{Status: StatusOK})
+	responser.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (c *Checker) Ready(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+
+	status := http.StatusOK
+	if report.Status == StatusUnavailable || report.Status == StatusShutdown {
+		status = http.StatusServiceUnavailable
+	}
+
+	resp, _ := json.Marshal(report)
+	w.Header().Set("Cache-Control", "no-store")
+	responser.WriteJSON(w, status, resp)
+}
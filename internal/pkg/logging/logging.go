@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ctxKey struct{}
+
+// RequestInfo is shared by pointer so that fields learned deeper in the chain, such as the user, reach the access log.
+type RequestInfo struct {
+	ID     string
+	Route  string
+	UserID int
+}
+
+func WithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, ctxKey{}, info)
+}
+
+func Info(ctx context.Context) *RequestInfo {
+	info, _ := ctx.Value(ctxKey{}).(*RequestInfo)
+	return info
+}
+
+func RequestID(ctx context.Context) string {
+	if info := Info(ctx); info != nil {
+		return info.ID
+	}
+	return ""
+}
+
+func SetUserID(ctx context.Context, userID int) {
+	if info := Info(ctx); info != nil {
+		info.UserID = userID
+	}
+}
+
+func Entry(ctx context.Context, logger *logrus.Logger) *logrus.Entry {
+	info := Info(ctx)
+	if info == nil {
+		return logrus.NewEntry(logger)
+	}
+
+	fields := logrus.Fields{"request_id": info.ID}
+	if info.Route != "" {
+		fields["route"] = info.Route
+	}
+	if info.UserID != 0 {
+		fields["user_id"] = info.UserID
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		fields["trace_id"] = sc.TraceID().String()
+		fields["span_id"] = sc.SpanID().String()
+	}
+
+	return logger.WithFields(fields)
+}
+
+func Configure(logger *logrus.Logger, level, format string) error {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	logger.SetLevel(lvl)
+
+	switch format {
+	case "json":
+		logger.SetFormatter(&logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano})
+	default:
+		logger.SetFormatter(&logrus.TextFormatter{TimestampFormat: time.DateTime, FullTimestamp: true})
+	}
+
+	return nil
+}
@@ -0,0 +1,484 @@
+package memstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"banner-service/internal/models"
+)
+
+// The constraints of the Postgres schema, checked by the Put methods of Tx.
+var (
+	ErrUniqueViolation     = errors.New("unique violation")
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	errReadOnly            = errors.New("write in a read-only transaction")
+)
+
+type Pair struct {
+	TagID, FeatureID int
+}
+
+// Banner is a row of banner with its tags, feature and old versions. FeatureID is 0 when it has no tags.
+type Banner struct {
+	BannerID       int                    `json:"banner_id"`
+	TagIDs         []int                  `json:"tag_ids"`
+	FeatureID      int                    `json:"feature_id"`
+	Content        json.RawMessage        `json:"content"`
+	IsActive       bool                   `json:"is_active"`
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+	CurrentVersion int                    `json:"current_version"`
+	TotalVersions  int                    `json:"total_versions"`
+	Versions       []models.BannerVersion `json:"versions"`
+}
+
+func (b *Banner) clone() *Banner {
+	c := *b
+	c.Content = append(json.RawMessage(nil), b.Content...)
+	c.TagIDs = append([]int(nil), b.TagIDs...)
+	c.Versions = append([]models.BannerVersion(nil), b.Versions...)
+	return &c
+}
+
+func (b *Banner) pairs() []Pair {
+	pairs := make([]Pair, 0, len(b.TagIDs))
+	for _, tagID := range b.TagIDs {
+		pairs = append(pairs, Pair{TagID: tagID, FeatureID: b.FeatureID})
+	}
+	return pairs
+}
+
+type SignInFailure struct {
+	Login     string    `json:"login"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store keeps the tables of the service in memory. Transactions are serialized: a write holds the lock
+// until it commits, so the repositories get the isolation they rely on from Postgres.
+type Store struct {
+	mu           sync.RWMutex
+	tags         map[int]bool
+	features     map[int]bool
+	banners      map[int]*Banner
+	pairs        map[Pair]int
+	users        map[string]*models.User
+	failures     []SignInFailure
+	lastBannerID int
+	lastUserID   int
+	journal      func(Change) error
+}
+
+// Change is what a transaction commits. A banner set to nil is deleted.
+type Change struct {
+	Banners      map[int]*Banner `json:"banners,omitempty"`
+	Users        []models.User   `json:"users,omitempty"`
+	Failures     []SignInFailure `json:"failures,omitempty"`
+	LastBannerID int             `json:"last_banner_id"`
+	LastUserID   int             `json:"last_user_id"`
+}
+
+// Snapshot is the whole store.
+type Snapshot struct {
+	Tags         []int           `json:"tags"`
+	Features     []int           `json:"features"`
+	Banners      []*Banner       `json:"banners"`
+	Users        []models.User   `json:"users"`
+	Failures     []SignInFailure `json:"failures"`
+	LastBannerID int             `json:"last_banner_id"`
+	LastUserID   int             `json:"last_user_id"`
+}
+
+func New() *Store {
+	return &Store{
+		tags:     make(map[int]bool),
+		features: make(map[int]bool),
+		banners:  make(map[int]*Banner),
+		pairs:    make(map[Pair]int),
+		users:    make(map[string]*models.User),
+	}
+}
+
+// Now is the current time as a Postgres TIMESTAMP keeps it.
+func Now() time.Time {
+	return Timestamp(time.Now().UTC())
+}
+
+// Timestamp drops the time zone and the nanoseconds of t the way pgx writes a TIMESTAMP.
+func Timestamp(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC).
+		Truncate(time.Microsecond)
+}
+
+func (s *Store) AddTags(ids ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		s.tags[id] = true
+	}
+}
+
+func (s *Store) AddFeatures(ids ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		s.features[id] = true
+	}
+}
+
+// Read runs fn with a snapshot that cannot be written.
+func (s *Store) Read(fn func(tx *Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(&Tx{s: s})
+}
+
+// Write runs fn in a transaction that is committed when fn returns nil and discarded otherwise.
+func (s *Store) Write(fn func(tx *Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &Tx{
+		s:       s,
+		write:   true,
+		banners: make(map[int]*Banner),
+		pairs:   make(map[Pair]int),
+		users:   make(map[string]*models.User),
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	c := tx.change()
+	if s.journal != nil {
+		if err := s.journal(c); err != nil {
+			return fmt.Errorf("failed to write the journal: %w", err)
+		}
+	}
+	s.apply(c)
+	return nil
+}
+
+// SetJournal makes Write pass every change to fn before it is committed, a change fn fails is discarded.
+func (s *Store) SetJournal(fn func(Change) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.journal = fn
+}
+
+// Apply commits a change written by another store, the journal is not called.
+func (s *Store) Apply(c Change) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apply(c)
+}
+
+func (s *Store) apply(c Change) {
+	for id, b := range c.Banners {
+		if old := s.banners[id]; old != nil {
+			for _, p := range old.pairs() {
+				delete(s.pairs, p)
+			}
+		}
+		if b == nil {
+			delete(s.banners, id)
+		} else {
+			s.banners[id] = b
+		}
+	}
+	// The pairs are taken once all are released, a banner of the change may take one another has left.
+	for _, b := range c.Banners {
+		if b == nil {
+			continue
+		}
+		for _, p := range b.pairs() {
+			s.pairs[p] = b.BannerID
+		}
+	}
+	for i := range c.Users {
+		u := c.Users[i]
+		s.users[u.Login] = &u
+	}
+	s.failures = append(s.failures, c.Failures...)
+	s.lastBannerID = max(s.lastBannerID, c.LastBannerID)
+	s.lastUserID = max(s.lastUserID, c.LastUserID)
+}
+
+// Snapshot copies the store, fn runs before any other write so that it can also truncate a journal.
+func (s *Store) Snapshot(fn func(Snapshot) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := Snapshot{
+		Tags:         sortedKeys(s.tags),
+		Features:     sortedKeys(s.features),
+		Banners:      make([]*Banner, 0, len(s.banners)),
+		Users:        make([]models.User, 0, len(s.users)),
+		Failures:     append([]SignInFailure(nil), s.failures...),
+		LastBannerID: s.lastBannerID,
+		LastUserID:   s.lastUserID,
+	}
+	// A committed banner is never changed in place, the snapshot can share it.
+	for _, b := range s.banners {
+		snap.Banners = append(snap.Banners, b)
+	}
+	sort.Slice(snap.Banners, func(i, j int) bool { return snap.Banners[i].BannerID < snap.Banners[j].BannerID })
+	for _, u := range s.users {
+		snap.Users = append(snap.Users, *u)
+	}
+	sort.Slice(snap.Users, func(i, j int) bool { return snap.Users[i].UserID < snap.Users[j].UserID })
+
+	return fn(snap)
+}
+
+// Restore replaces everything in the store with the snapshot.
+func (s *Store) Restore(snap Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tags, s.features = make(map[int]bool), make(map[int]bool)
+	for _, id := range snap.Tags {
+		s.tags[id] = true
+	}
+	for _, id := range snap.Features {
+		s.features[id] = true
+	}
+	s.banners, s.pairs, s.users = make(map[int]*Banner), make(map[Pair]int), make(map[string]*models.User)
+	s.failures, s.lastBannerID, s.lastUserID = nil, 0, 0
+
+	c := Change{Banners: make(map[int]*Banner, len(snap.Banners)), Users: snap.Users, Failures: snap.Failures,
+		LastBannerID: snap.LastBannerID, LastUserID: snap.LastUserID}
+	for _, b := range snap.Banners {
+		c.Banners[b.BannerID] = b
+	}
+	s.apply(c)
+}
+
+func sortedKeys(m map[int]bool) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// Tx sees the store with its own changes applied. A banner or pair set to nil or 0 is deleted.
+type Tx struct {
+	s        *Store
+	write    bool
+	banners  map[int]*Banner
+	pairs    map[Pair]int
+	users    map[string]*models.User
+	failures []SignInFailure
+}
+
+func (tx *Tx) change() Change {
+	c := Change{
+		Banners:      tx.banners,
+		Users:        make([]models.User, 0, len(tx.users)),
+		Failures:     tx.failures,
+		LastBannerID: tx.s.lastBannerID,
+		LastUserID:   tx.s.lastUserID,
+	}
+	for _, u := range tx.users {
+		c.Users = append(c.Users, *u)
+	}
+	sort.Slice(c.Users, func(i, j int) bool { return c.Users[i].UserID < c.Users[j].UserID })
+	return c
+}
+
+// Savepoint discards the changes of fn when it fails, the rest of the transaction goes on.
+func (tx *Tx) Savepoint(fn func() error) error {
+	banners := make(map[int]*Banner, len(tx.banners))
+	for k, v := range tx.banners {
+		banners[k] = v
+	}
+	pairs := make(map[Pair]int, len(tx.pairs))
+	for k, v := range tx.pairs {
+		pairs[k] = v
+	}
+	users := make(map[string]*models.User, len(tx.users))
+	for k, v := range tx.users {
+		users[k] = v
+	}
+	failures := len(tx.failures)
+
+	if err := fn(); err != nil {
+		tx.banners, tx.pairs, tx.users, tx.failures = banners, pairs, users, tx.failures[:failures]
+		return err
+	}
+	return nil
+}
+
+func (tx *Tx) Tag(id int) bool {
+	return tx.s.tags[id]
+}
+
+func (tx *Tx) Feature(id int) bool {
+	return tx.s.features[id]
+}
+
+func (tx *Tx) banner(id int) *Banner {
+	if b, ok := tx.banners[id]; ok {
+		return b
+	}
+	return tx.s.banners[id]
+}
+
+// Banner returns a copy of the banner, changing it has no effect until PutBanner.
+func (tx *Tx) Banner(id int) (Banner, bool) {
+	b := tx.banner(id)
+	if b == nil {
+		return Banner{}, false
+	}
+	return *b.clone(), true
+}
+
+// BannerID returns the banner that has the tag and feature.
+func (tx *Tx) BannerID(p Pair) (int, bool) {
+	id, ok := tx.pairs[p]
+	if !ok {
+		id = tx.s.pairs[p]
+	}
+	return id, id != 0
+}
+
+// Banners calls fn for every banner in id order until it returns false. The banners must not be changed.
+func (tx *Tx) Banners(fn func(b *Banner) bool) {
+	ids := make([]int, 0, len(tx.s.banners)+len(tx.banners))
+	for id := range tx.s.banners {
+		if _, ok := tx.banners[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	for id, b := range tx.banners {
+		if b != nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		if !fn(tx.banner(id)) {
+			return
+		}
+	}
+}
+
+// NextBannerID takes the next id of the sequence, like SERIAL it is not given back on rollback.
+func (tx *Tx) NextBannerID() int {
+	tx.s.lastBannerID++
+	return tx.s.lastBannerID
+}
+
+// PutBanner inserts or replaces the banner after checking the tags, feature and versions.
+func (tx *Tx) PutBanner(b Banner) error {
+	if !tx.write {
+		return errReadOnly
+	}
+
+	if len(b.TagIDs) == 0 {
+		b.FeatureID = 0
+	} else if !tx.Feature(b.FeatureID) {
+		return fmt.Errorf("%w: feature %d does not exist", ErrForeignKeyViolation, b.FeatureID)
+	}
+	seen := make(map[int]bool, len(b.TagIDs))
+	for _, tagID := range b.TagIDs {
+		if !tx.Tag(tagID) {
+			return fmt.Errorf("%w: tag %d does not exist", ErrForeignKeyViolation, tagID)
+		}
+		if seen[tagID] {
+			return fmt.Errorf("%w: tag %d and feature %d are repeated", ErrUniqueViolation, tagID, b.FeatureID)
+		}
+		seen[tagID] = true
+		if id, ok := tx.BannerID(Pair{TagID: tagID, FeatureID: b.FeatureID}); ok && id != b.BannerID {
+			return fmt.Errorf("%w: tag %d and feature %d belong to banner %d", ErrUniqueViolation,
+				tagID, b.FeatureID, id)
+		}
+	}
+	versions := make(map[int]bool, len(b.Versions))
+	for _, v := range b.Versions {
+		if versions[v.Version] {
+			return fmt.Errorf("%w: version %d of banner %d is repeated", ErrUniqueViolation, v.Version, b.BannerID)
+		}
+		versions[v.Version] = true
+	}
+
+	if old := tx.banner(b.BannerID); old != nil {
+		for _, p := range old.pairs() {
+			tx.pairs[p] = 0
+		}
+	}
+	row := b.clone()
+	sort.Ints(row.TagIDs)
+	sort.Slice(row.Versions, func(i, j int) bool { return row.Versions[i].Version < row.Versions[j].Version })
+	for _, p := range row.pairs() {
+		tx.pairs[p] = row.BannerID
+	}
+	tx.banners[row.BannerID] = row
+	return nil
+}
+
+// DeleteBanner deletes the banner with its tags and versions, it reports whether there was one.
+func (tx *Tx) DeleteBanner(id int) (bool, error) {
+	if !tx.write {
+		return false, errReadOnly
+	}
+
+	old := tx.banner(id)
+	if old == nil {
+		return false, nil
+	}
+	for _, p := range old.pairs() {
+		tx.pairs[p] = 0
+	}
+	tx.banners[id] = nil
+	return true, nil
+}
+
+func (tx *Tx) User(login string) (models.User, bool) {
+	u, ok := tx.users[login]
+	if !ok {
+		u, ok = tx.s.users[login]
+	}
+	if !ok {
+		return models.User{}, false
+	}
+	return *u, true
+}
+
+func (tx *Tx) NextUserID() int {
+	tx.s.lastUserID++
+	return tx.s.lastUserID
+}
+
+// PutUser inserts or replaces the user with its login, a tag of 0 means none.
+func (tx *Tx) PutUser(u models.User) error {
+	if !tx.write {
+		return errReadOnly
+	}
+
+	if old, ok := tx.User(u.Login); ok && old.UserID != u.UserID {
+		return fmt.Errorf("%w: login %s is taken", ErrUniqueViolation, u.Login)
+	}
+	if u.TagID != 0 && !tx.Tag(u.TagID) {
+		return fmt.Errorf("%w: tag %d does not exist", ErrForeignKeyViolation, u.TagID)
+	}
+
+	tx.users[u.Login] = &u
+	return nil
+}
+
+func (tx *Tx) AddSignInFailure(login, ip string) error {
+	if !tx.write {
+		return errReadOnly
+	}
+
+	tx.failures = append(tx.failures, SignInFailure{Login: login, IP: ip, CreatedAt: Now()})
+	return nil
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+const namespace = "banner_service"
+
+type Metrics struct {
+	registry      *prometheus.Registry
+	requests      *prometheus.CounterVec
+	latency       *prometheus.HistogramVec
+	cache         *prometheus.CounterVec
+	activeBanners prometheus.Gauge
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route, method and status code.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"route", "method", "code"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_operations_total",
+			Help:      "Cache operations by operation and result (hit, miss, error, ok).",
+		}, []string{"operation", "result"}),
+		activeBanners: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "active_banners",
+			Help:      "Number of active banners.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.latency,
+		m.cache,
+		m.activeBanners,
+	)
+
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func (m *Metrics) ObserveRequest(route, method string, code int, duration time.Duration) {
+	codeStr := strconv.Itoa(code)
+	m.requests.WithLabelValues(route, method, codeStr).Inc()
+	m.latency.WithLabelValues(route, method, codeStr).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveCache(operation, result string) {
+	m.cache.WithLabelValues(operation, result).Inc()
+}
+
+func (m *Metrics) RegisterPool(db *pgxpool.Pool) {
+	m.registry.MustRegister(newPoolCollector(db))
+}
+
+// TrackActiveBanners refreshes the active banner gauge until ctx is done; counting on every scrape is too costly.
+func (m *Metrics) TrackActiveBanners(ctx context.Context, interval time.Duration,
+	count func(context.Context) (int, error), logger *logrus.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		countCtx, cancel := context.WithTimeout(ctx, interval)
+		n, err := count(countCtx)
+		cancel()
+		if err != nil {
+			logger.Warn("failed to count active banners: ", err)
+		} else {
+			m.activeBanners.Set(float64(n))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type poolCollector struct {
+	db *pgxpool.Pool
+
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+}
+
+func newPoolCollector(db *pgxpool.Pool) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "pgxpool", name), help, nil, nil)
+	}
+
+	return &poolCollector{
+		db:                   db,
+		acquireCount:         desc("acquire_total", "Number of successful connection acquires."),
+		acquireDuration:      desc("acquire_wait_seconds_total", "Total time spent waiting for a connection."),
+		emptyAcquireCount:    desc("empty_acquire_total", "Number of acquires that had to wait for a connection."),
+		canceledAcquireCount: desc("canceled_acquire_total", "Number of acquires canceled by context."),
+		acquiredConns:        desc("acquired_connections", "Number of currently acquired connections."),
+		idleConns:            desc("idle_connections", "Number of currently idle connections."),
+		totalConns:           desc("total_connections", "Total number of connections in the pool."),
+		maxConns:             desc("max_connections", "Maximum size of the pool."),
+	}
+}
+
+func (pc *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pc.acquireCount
+	ch <- pc.acquireDuration
+	ch <- pc.emptyAcquireCount
+	ch <- pc.canceledAcquireCount
+	ch <- pc.acquiredConns
+	ch <- pc.idleConns
+	ch <- pc.totalConns
+	ch <- pc.maxConns
+}
+
+func (pc *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := pc.db.Stat()
+
+	ch <- prometheus.MustNewConstMetric(pc.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(pc.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(pc.emptyAcquireCount, prometheus.CounterValue,
+		float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(pc.canceledAcquireCount, prometheus.CounterValue,
+		float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(pc.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(pc.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(pc.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(pc.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+}
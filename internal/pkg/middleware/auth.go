@@ -8,6 +8,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"banner-service/internal/pkg/logging"
 	"banner-service/internal/utils/jwter"
 	"banner-service/internal/utils/responser"
 )
@@ -27,11 +28,11 @@ func (mw *MwAuth) Auth(onlyAdmin bool, next http.Handler) http.Handler {
 		if err != nil {
 			switch {
 			case errors.Is(err, http.ErrNoCookie):
-				mw.log.Debug("token cookie not found", err)
+				logging.Entry(r.Context(), mw.log).Debug("token cookie not found", err)
 				responser.WriteStatus(w, http.StatusUnauthorized)
 				return
 			default:
-				mw.log.Error("faild to get token cookie", err)
+				logging.Entry(r.Context(), mw.log).Error("faild to get token cookie", err)
 				responser.WriteStatus(w, http.StatusUnauthorized)
 				return
 			}
@@ -39,31 +40,38 @@ func (mw *MwAuth) Auth(onlyAdmin bool, next http.Handler) http.Handler {
 
 		claims, err := mw.tokenManager.ParseJWT(tokenCookie.Value)
 		if err != nil {
-			mw.log.Error("jws token is invalid auth ", err)
+			switch {
+			case errors.Is(err, jwter.ErrTokenExpired):
+				logging.Entry(r.Context(), mw.log).Debug("jwt token expired ", err)
+			default:
+				logging.Entry(r.Context(), mw.log).Error("jwt token is invalid auth ", err)
+			}
 			responser.WriteStatus(w, http.StatusUnauthorized)
 			return
 		}
 
-		if onlyAdmin && !claims["is_admin"].(bool) {
+		logging.SetUserID(r.Context(), claims.UserID)
+
+		if onlyAdmin && !claims.IsAdmin {
 			responser.WriteStatus(w, http.StatusForbidden)
 			return
 		}
 
 		var tagID int
 		tagIDStr := r.URL.Query().Get("tag_id")
-		if tagIDStr != "" && !claims["is_admin"].(bool) {
+		if tagIDStr != "" && !claims.IsAdmin {
 			tagID, err = strconv.Atoi(tagIDStr)
 			if err == nil {
-				if tagID != int(claims["tag_id"].(float64)) {
+				if tagID != claims.TagID {
 					responser.WriteStatus(w, http.StatusForbidden)
 					return
 				}
 			}
 		}
 
-		ctx := context.WithValue(r.Context(), "user_id", int(claims["user_id"].(float64)))
-		ctx = context.WithValue(ctx, "is_admin", claims["is_admin"].(bool))
-		ctx = context.WithValue(ctx, "tag_id", int(claims["tag_id"].(float64)))
+		ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
+		ctx = context.WithValue(ctx, "is_admin", claims.IsAdmin)
+		ctx = context.WithValue(ctx, "tag_id", claims.TagID)
 		r = r.WithContext(ctx)
 		next.ServeHTTP(w, r)
 	})
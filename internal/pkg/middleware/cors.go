@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type CORSPolicy struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	MaxAge         time.Duration
+}
+
+// MwCORS wraps the whole router rather than being a route middleware, since preflight requests match no route.
+type MwCORS struct {
+	policy atomic.Pointer[CORSPolicy]
+}
+
+func NewCORS(policy CORSPolicy) *MwCORS {
+	mw := &MwCORS{}
+	mw.SetPolicy(policy)
+	return mw
+}
+
+func (mw *MwCORS) SetPolicy(policy CORSPolicy) {
+	mw.policy.Store(&policy)
+}
+
+func (mw *MwCORS) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		policy := mw.policy.Load()
+		w.Header().Add("Vary", "Origin")
+
+		allowed, wildcard := policy.allows(origin)
+		if !allowed {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if wildcard {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+			w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Retry-After, RateLimit-Limit, "+
+				"RateLimit-Remaining, RateLimit-Reset, ETag, X-Total-Count, X-Next-Cursor, Link")
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(policy.AllowedMethods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
+		if policy.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// allows reports whether origin is allowed and whether it matched a wildcard.
+func (p *CORSPolicy) allows(origin string) (bool, bool) {
+	for _, o := range p.AllowedOrigins {
+		if o == "*" {
+			return true, true
+		}
+		if strings.EqualFold(o, origin) {
+			return true, false
+		}
+	}
+
+	return false, false
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/pkg/logging"
+)
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+)
+
+type MwAccessLog struct {
+	log *logrus.Logger
+}
+
+func NewAccessLog(log *logrus.Logger) *MwAccessLog {
+	return &MwAccessLog{log}
+}
+
+func (mw *MwAccessLog) Log(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		info := &logging.RequestInfo{ID: id}
+		if current := mux.CurrentRoute(r); current != nil {
+			info.Route = current.GetName()
+		}
+
+		ctx := logging.WithRequestInfo(r.Context(), info)
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		// Trace runs before the access log, so the line carries the trace of the request like the handler logs.
+		entry := logging.Entry(ctx, mw.log).WithFields(logrus.Fields{
+			"route":       info.Route,
+			"method":      r.Method,
+			"path":        r.URL.Path,
+			"status":      sw.status,
+			"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+		})
+		switch {
+		case sw.status >= http.StatusInternalServerError:
+			entry.Error("request completed")
+		case sw.status >= http.StatusBadRequest:
+			entry.Warn("request completed")
+		default:
+			entry.Info("request completed")
+		}
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
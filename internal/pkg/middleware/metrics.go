@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"banner-service/internal/pkg/metrics"
+)
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	sw.status = status
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach Flush and deadlines of the underlying writer.
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+type MwMetrics struct {
+	metrics *metrics.Metrics
+}
+
+func NewMetrics(m *metrics.Metrics) *MwMetrics {
+	return &MwMetrics{m}
+}
+
+func (mw *MwMetrics) Measure(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil && current.GetName() != "" {
+			route = current.GetName()
+		}
+
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		mw.metrics.ObserveRequest(route, r.Method, sw.status, time.Since(start))
+	})
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/pkg/logging"
+	"banner-service/internal/pkg/ratelimit"
+	"banner-service/internal/utils/clientip"
+	"banner-service/internal/utils/responser"
+)
+
+type MwRateLimit struct {
+	log     *logrus.Logger
+	limiter *ratelimit.TokenBucket
+}
+
+func NewRateLimit(log *logrus.Logger, limiter *ratelimit.TokenBucket) *MwRateLimit {
+	return &MwRateLimit{log, limiter}
+}
+
+func (mw *MwRateLimit) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := ""
+		if current := mux.CurrentRoute(r); current != nil {
+			route = current.GetName()
+		}
+
+		d, err := mw.limiter.Take(r.Context(), route, clientKey(r))
+		if err != nil {
+			logging.Entry(r.Context(), mw.log).Error("failed to check rate limit ", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if d.Limit > 0 {
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(int(d.Reset.Seconds())))
+		}
+
+		if !d.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(d.RetryAfter.Seconds())))
+			responser.WriteError(w, http.StatusTooManyRequests, errors.New("rate limit exceeded"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey trusts only what auth has validated, anything a client sends itself, like an X-API-Key header, could
+// be changed on every request to get a fresh bucket.
+func clientKey(r *http.Request) string {
+	if userID, ok := r.Context().Value("user_id").(int); ok {
+		return "user:" + strconv.Itoa(userID)
+	}
+
+	return "ip:" + clientip.FromRequest(r)
+}
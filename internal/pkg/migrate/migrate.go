@@ -0,0 +1,265 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	createTable = `CREATE TABLE IF NOT EXISTS schema_migrations(
+                    version    BIGINT PRIMARY KEY,
+                    name       TEXT NOT NULL,
+                    applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+                 );`
+	getApplied    = `SELECT version, applied_at FROM schema_migrations;`
+	insertApplied = `INSERT INTO schema_migrations(version, name) VALUES ($1, $2);`
+	deleteApplied = `DELETE FROM schema_migrations WHERE version=$1;`
+
+	// lockID serialises migrations between instances starting at the same time.
+	lockID = 7251003
+)
+
+var (
+	ErrNoDownMigration = errors.New("migration has no down file")
+	ErrUnknownVersion  = errors.New("applied migration is not known to this binary")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *pgxpool.Pool
+	migrations []Migration
+}
+
+// Load reads migrations from the root of fsys, ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		m := fileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", e.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration version %d is used by %s and %s", version, mig.Name, m[2])
+		}
+
+		if m[3] == "up" {
+			mig.Up = string(content)
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func New(db *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up applies every pending migration, each in its own transaction, and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+			if err := run(ctx, conn, mig.Up, insertApplied, mig.Version, mig.Name); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			applied = append(applied, mig)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts the latest applied migration and returns it, or nil when nothing is applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		var latest int64 = -1
+		for v := range done {
+			if v > latest {
+				latest = v
+			}
+		}
+		if latest < 0 {
+			return nil
+		}
+
+		mig, ok := m.find(latest)
+		if !ok {
+			return fmt.Errorf("%w: %d", ErrUnknownVersion, latest)
+		}
+		if mig.Down == "" {
+			return fmt.Errorf("%w: %d_%s", ErrNoDownMigration, mig.Version, mig.Name)
+		}
+		if err := run(ctx, conn, mig.Down, deleteApplied, mig.Version); err != nil {
+			return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		reverted = &mig
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, createTable); err != nil {
+		return nil, err
+	}
+	done, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Migration: mig}
+		if at, ok := done[mig.Version]; ok {
+			s.AppliedAt = &at
+		}
+		statuses = append(statuses, s)
+	}
+
+	return statuses, nil
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return mig, true
+		}
+	}
+	return Migration{}, false
+}
+
+func (m *Migrator) locked(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1);`, lockID); err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1);`, lockID)
+	}()
+
+	if _, err = conn.Exec(ctx, createTable); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.Query(ctx, getApplied)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			version int64
+			at      time.Time
+		)
+		if err = rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		done[version] = at
+	}
+
+	return done, rows.Err()
+}
+
+func run(ctx context.Context, conn *pgxpool.Conn, script, record string, args ...interface{}) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, script); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, record, args...)
+		return err
+	})
+}
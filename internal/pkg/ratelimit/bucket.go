@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Limit struct {
+	Rate  float64 `yaml:"rate" env:"RATE"`
+	Burst int     `yaml:"burst" env:"BURST"`
+}
+
+// SetValue parses "rate/burst", which is how limits are written in environment variables.
+func (l *Limit) SetValue(s string) error {
+	rate, burst, ok := strings.Cut(s, "/")
+	if !ok {
+		return fmt.Errorf("invalid limit %q, expected rate/burst", s)
+	}
+
+	r, err := strconv.ParseFloat(rate, 64)
+	if err != nil {
+		return fmt.Errorf("invalid limit rate %q: %w", rate, err)
+	}
+	b, err := strconv.Atoi(burst)
+	if err != nil {
+		return fmt.Errorf("invalid limit burst %q: %w", burst, err)
+	}
+
+	*l = Limit{Rate: r, Burst: b}
+	return nil
+}
+
+type Decision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type TokenBucket struct {
+	store Store
+
+	mu       sync.RWMutex
+	fallback Limit
+	routes   map[string]Limit
+}
+
+func NewTokenBucket(store Store, fallback Limit, routes map[string]Limit) *TokenBucket {
+	return &TokenBucket{store: store, fallback: fallback, routes: routes}
+}
+
+func (tb *TokenBucket) SetLimits(fallback Limit, routes map[string]Limit) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.fallback = fallback
+	tb.routes = routes
+}
+
+func (tb *TokenBucket) limitFor(route string) Limit {
+	tb.mu.RLock()
+	defer tb.mu.RUnlock()
+
+	if l, ok := tb.routes[route]; ok {
+		return l
+	}
+	return tb.fallback
+}
+
+func (tb *TokenBucket) Take(ctx context.Context, route, client string) (Decision, error) {
+	l := tb.limitFor(route)
+	if l.Rate <= 0 || l.Burst <= 0 {
+		return Decision{Allowed: true}, nil
+	}
+
+	allowed, tokens, err := tb.store.Take(ctx, "ratelimit:"+route+":"+client, l.Rate, l.Burst, time.Now())
+	if err != nil {
+		return Decision{Allowed: true}, err
+	}
+
+	d := Decision{
+		Allowed:   allowed,
+		Limit:     l.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     secondsFor(float64(l.Burst)-tokens, l.Rate),
+	}
+
+	if !allowed {
+		d.RetryAfter = secondsFor(1-tokens, l.Rate)
+	}
+
+	return d, nil
+}
+
+func secondsFor(tokens, rate float64) time.Duration {
+	return time.Duration(math.Ceil(tokens/rate)) * time.Second
+}
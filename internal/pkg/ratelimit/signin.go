@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type LimitError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", e.Reason, e.RetryAfter.Round(time.Second))
+}
+
+type SignInLimits struct {
+	LoginAttempts    int
+	IPAttempts       int
+	Window           time.Duration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+}
+
+type SignInGuard struct {
+	store Store
+
+	mu     sync.RWMutex
+	limits SignInLimits
+}
+
+func NewSignInGuard(store Store, limits SignInLimits) *SignInGuard {
+	return &SignInGuard{store: store, limits: limits}
+}
+
+func (g *SignInGuard) Limits() SignInLimits {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.limits
+}
+
+func (g *SignInGuard) SetLimits(limits SignInLimits) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.limits = limits
+}
+
+// Allow and Fail read the limits once, a reload in the middle of a call must not mix old and new ones.
+func (g *SignInGuard) Allow(ctx context.Context, login, ip string) error {
+	lockTTL, err := g.store.LockTTL(ctx, lockKey(login))
+	if err != nil {
+		return err
+	}
+
+	if lockTTL > 0 {
+		return &LimitError{Reason: "account is temporarily locked", RetryAfter: lockTTL}
+	}
+
+	limits := g.Limits()
+	now := time.Now()
+
+	if err = g.hit(ctx, "signin:login:"+login, limits.LoginAttempts, limits.Window, now); err != nil {
+		return err
+	}
+
+	return g.hit(ctx, "signin:ip:"+ip, limits.IPAttempts, limits.Window, now)
+}
+
+func (g *SignInGuard) Fail(ctx context.Context, login string) error {
+	limits := g.Limits()
+
+	failures, err := g.store.Incr(ctx, failuresKey(login), limits.LockoutDuration)
+	if err != nil {
+		return err
+	}
+
+	if limits.LockoutThreshold > 0 && failures >= limits.LockoutThreshold {
+		if err = g.store.Lock(ctx, lockKey(login), limits.LockoutDuration); err != nil {
+			return err
+		}
+
+		return g.store.Delete(ctx, failuresKey(login))
+	}
+
+	return nil
+}
+
+func (g *SignInGuard) Succeed(ctx context.Context, login string) error {
+	return g.store.Delete(ctx, failuresKey(login))
+}
+
+func (g *SignInGuard) hit(ctx context.Context, key string, limit int, window time.Duration, now time.Time) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	count, oldest, err := g.store.Hit(ctx, key, now, window)
+	if err != nil {
+		return err
+	}
+
+	if count > limit {
+		return &LimitError{Reason: "too many sign in attempts", RetryAfter: oldest.Add(window).Sub(now)}
+	}
+
+	return nil
+}
+
+func failuresKey(login string) string {
+	return "signin:failures:" + login
+}
+
+func lockKey(login string) string {
+	return "signin:lock:" + login
+}
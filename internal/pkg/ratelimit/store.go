@@ -0,0 +1,400 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const takeScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or burst
+local ts = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`
+
+type Store interface {
+	Take(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, float64, error)
+	Hit(ctx context.Context, key string, now time.Time, window time.Duration) (int, time.Time, error)
+	Incr(ctx context.Context, key string, ttl time.Duration) (int, error)
+	Lock(ctx context.Context, key string, ttl time.Duration) error
+	LockTTL(ctx context.Context, key string) (time.Duration, error)
+	Delete(ctx context.Context, keys ...string) error
+}
+
+type RedisStore struct {
+	client *redis.Client
+	take   *redis.Script
+	seq    atomic.Uint64
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client, take: redis.NewScript(takeScript)}
+}
+
+func (rs *RedisStore) Take(ctx context.Context, key string, rate float64, burst int,
+	now time.Time) (bool, float64, error) {
+	res, err := rs.take.Run(ctx, rs.client, []string{key}, rate, burst, now.UnixMilli()).Slice()
+	if err != nil {
+		return false, 0, err
+	}
+
+	if len(res) != 2 {
+		return false, 0, fmt.Errorf("unexpected token bucket reply: %v", res)
+	}
+
+	allowed, _ := res[0].(int64)
+	tokensStr, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return false, 0, err
+	}
+
+	return allowed == 1, tokens, nil
+}
+
+func (rs *RedisStore) Hit(ctx context.Context, key string, now time.Time,
+	window time.Duration) (int, time.Time, error) {
+	member := strconv.FormatInt(now.UnixNano(), 10) + "-" + strconv.FormatUint(rs.seq.Add(1), 10)
+
+	var card *redis.IntCmd
+	var oldest *redis.ZSliceCmd
+	_, err := rs.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.Add(-window).UnixNano(), 10))
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.UnixNano()), Member: member})
+		card = pipe.ZCard(ctx, key)
+		oldest = pipe.ZRangeWithScores(ctx, key, 0, 0)
+		pipe.PExpire(ctx, key, window)
+		return nil
+	})
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	first := now
+	if z := oldest.Val(); len(z) > 0 {
+		first = time.Unix(0, int64(z[0].Score))
+	}
+
+	return int(card.Val()), first, nil
+}
+
+func (rs *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	var incr *redis.IntCmd
+	_, err := rs.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		pipe.PExpire(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(incr.Val()), nil
+}
+
+func (rs *RedisStore) Lock(ctx context.Context, key string, ttl time.Duration) error {
+	return rs.client.Set(ctx, key, 1, ttl).Err()
+}
+
+func (rs *RedisStore) LockTTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := rs.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+func (rs *RedisStore) Delete(ctx context.Context, keys ...string) error {
+	return rs.client.Del(ctx, keys...).Err()
+}
+
+// sweepInterval is how often MemoryStore drops the keys that expired, it sweeps on the first call after it.
+const sweepInterval = time.Minute
+
+type counter struct {
+	value     int
+	expiresAt time.Time
+}
+
+// bucket is full again at expiresAt, a full bucket is the same as no bucket.
+type bucket struct {
+	tokens    float64
+	ts        time.Time
+	expiresAt time.Time
+}
+
+// window expires when its newest hit is out of it.
+type window struct {
+	hits      []time.Time
+	expiresAt time.Time
+}
+
+// MemoryStore keeps the keys of a single process. Keys expire as they do in Redis, so clients that are gone
+// take no memory.
+type MemoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]bucket
+	windows  map[string]window
+	counters map[string]counter
+	locks    map[string]time.Time
+	swept    time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  make(map[string]bucket),
+		windows:  make(map[string]window),
+		counters: make(map[string]counter),
+		locks:    make(map[string]time.Time),
+		swept:    time.Now(),
+	}
+}
+
+// Len is the number of keys the store holds, expired ones included until they are swept.
+func (ms *MemoryStore) Len() int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return len(ms.buckets) + len(ms.windows) + len(ms.counters) + len(ms.locks)
+}
+
+// sweep must be called with ms.mu held.
+func (ms *MemoryStore) sweep(now time.Time) {
+	if now.Sub(ms.swept) < sweepInterval {
+		return
+	}
+	ms.swept = now
+
+	for key, b := range ms.buckets {
+		if !b.expiresAt.After(now) {
+			delete(ms.buckets, key)
+		}
+	}
+	for key, w := range ms.windows {
+		if !w.expiresAt.After(now) {
+			delete(ms.windows, key)
+		}
+	}
+	for key, c := range ms.counters {
+		if !c.expiresAt.After(now) {
+			delete(ms.counters, key)
+		}
+	}
+	for key, expiresAt := range ms.locks {
+		if !expiresAt.After(now) {
+			delete(ms.locks, key)
+		}
+	}
+}
+
+func (ms *MemoryStore) Take(_ context.Context, key string, rate float64, burst int,
+	now time.Time) (bool, float64, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.sweep(now)
+
+	b, ok := ms.buckets[key]
+	if !ok {
+		b = bucket{tokens: float64(burst), ts: now}
+	}
+
+	elapsed := math.Max(0, now.Sub(b.ts).Seconds())
+	b.tokens = math.Min(float64(burst), b.tokens+elapsed*rate)
+	b.ts = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.expiresAt = now.Add(time.Duration((float64(burst) - b.tokens) / rate * float64(time.Second)))
+	ms.buckets[key] = b
+
+	return allowed, b.tokens, nil
+}
+
+func (ms *MemoryStore) Hit(_ context.Context, key string, now time.Time,
+	window time.Duration) (int, time.Time, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.sweep(now)
+
+	from := now.Add(-window)
+	w := ms.windows[key]
+	i := 0
+	for i < len(w.hits) && !w.hits[i].After(from) {
+		i++
+	}
+	w.hits = append(w.hits[i:], now)
+	w.expiresAt = now.Add(window)
+	ms.windows[key] = w
+
+	return len(w.hits), w.hits[0], nil
+}
+
+func (ms *MemoryStore) Incr(_ context.Context, key string, ttl time.Duration) (int, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now()
+	ms.sweep(now)
+
+	c := ms.counters[key]
+	if !c.expiresAt.After(now) {
+		c.value = 0
+	}
+	c.value++
+	c.expiresAt = now.Add(ttl)
+	ms.counters[key] = c
+
+	return c.value, nil
+}
+
+func (ms *MemoryStore) Lock(_ context.Context, key string, ttl time.Duration) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now()
+	ms.sweep(now)
+
+	ms.locks[key] = now.Add(ttl)
+	return nil
+}
+
+func (ms *MemoryStore) LockTTL(_ context.Context, key string) (time.Duration, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	expiresAt, ok := ms.locks[key]
+	if !ok {
+		return 0, nil
+	}
+
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		delete(ms.locks, key)
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+func (ms *MemoryStore) Delete(_ context.Context, keys ...string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, key := range keys {
+		delete(ms.buckets, key)
+		delete(ms.windows, key)
+		delete(ms.counters, key)
+		delete(ms.locks, key)
+	}
+	return nil
+}
+
+// FallbackStore uses primary and switches to fallback for any call primary fails. It logs when it switches
+// and when primary is back, not on every call.
+type FallbackStore struct {
+	primary  Store
+	fallback Store
+	logger   *logrus.Logger
+	degraded atomic.Bool
+}
+
+func NewFallbackStore(primary, fallback Store, logger *logrus.Logger) *FallbackStore {
+	return &FallbackStore{primary: primary, fallback: fallback, logger: logger}
+}
+
+func (fs *FallbackStore) failed(err error) {
+	if fs.degraded.CompareAndSwap(false, true) {
+		fs.logger.Warn("rate limit store unavailable, using fallback: ", err)
+	}
+}
+
+func (fs *FallbackStore) succeeded() {
+	if fs.degraded.CompareAndSwap(true, false) {
+		fs.logger.Info("rate limit store is back")
+	}
+}
+
+func (fs *FallbackStore) Take(ctx context.Context, key string, rate float64, burst int,
+	now time.Time) (bool, float64, error) {
+	allowed, tokens, err := fs.primary.Take(ctx, key, rate, burst, now)
+	if err != nil {
+		fs.failed(err)
+		return fs.fallback.Take(ctx, key, rate, burst, now)
+	}
+	fs.succeeded()
+	return allowed, tokens, nil
+}
+
+func (fs *FallbackStore) Hit(ctx context.Context, key string, now time.Time,
+	window time.Duration) (int, time.Time, error) {
+	count, oldest, err := fs.primary.Hit(ctx, key, now, window)
+	if err != nil {
+		fs.failed(err)
+		return fs.fallback.Hit(ctx, key, now, window)
+	}
+	fs.succeeded()
+	return count, oldest, nil
+}
+
+func (fs *FallbackStore) Incr(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	value, err := fs.primary.Incr(ctx, key, ttl)
+	if err != nil {
+		fs.failed(err)
+		return fs.fallback.Incr(ctx, key, ttl)
+	}
+	fs.succeeded()
+	return value, nil
+}
+
+func (fs *FallbackStore) Lock(ctx context.Context, key string, ttl time.Duration) error {
+	if err := fs.primary.Lock(ctx, key, ttl); err != nil {
+		fs.failed(err)
+		return fs.fallback.Lock(ctx, key, ttl)
+	}
+	fs.succeeded()
+	return nil
+}
+
+func (fs *FallbackStore) LockTTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := fs.primary.LockTTL(ctx, key)
+	if err != nil {
+		fs.failed(err)
+		return fs.fallback.LockTTL(ctx, key)
+	}
+	fs.succeeded()
+	return ttl, nil
+}
+
+func (fs *FallbackStore) Delete(ctx context.Context, keys ...string) error {
+	err := fs.primary.Delete(ctx, keys...)
+	if fallbackErr := fs.fallback.Delete(ctx, keys...); err == nil {
+		err = fallbackErr
+	}
+	return err
+}
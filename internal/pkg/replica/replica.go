@@ -0,0 +1,168 @@
+package replica
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type primaryKey struct{}
+
+// WithPrimary makes the reads of ctx go to the primary, for callers that must see their own writes.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func usePrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+type replica struct {
+	name    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+// Router sends reads to the healthy replicas in turn and to the primary when there are none.
+type Router struct {
+	primary  *pgxpool.Pool
+	replicas []*replica
+	next     atomic.Uint64
+	logger   *logrus.Logger
+}
+
+// NewRouter takes the replica pools by name, they are considered healthy until a check or a read fails.
+func NewRouter(primary *pgxpool.Pool, replicas map[string]*pgxpool.Pool, logger *logrus.Logger) *Router {
+	names := make([]string, 0, len(replicas))
+	for name := range replicas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	r := &Router{primary: primary, logger: logger}
+	for _, name := range names {
+		rep := &replica{name: name, pool: replicas[name]}
+		rep.healthy.Store(true)
+		r.replicas = append(r.replicas, rep)
+	}
+	return r
+}
+
+func (r *Router) pick(ctx context.Context) *replica {
+	if usePrimary(ctx) || len(r.replicas) == 0 {
+		return nil
+	}
+
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		rep := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]
+		if rep.healthy.Load() {
+			return rep
+		}
+	}
+	return nil
+}
+
+// Read runs fn on a replica. When the replica cannot be reached it is marked down until the next check
+// and fn runs again on the primary; errors of the query itself are returned as they are.
+func (r *Router) Read(ctx context.Context, fn func(db *pgxpool.Pool) error) error {
+	rep := r.pick(ctx)
+	if rep == nil {
+		return fn(r.primary)
+	}
+
+	err := fn(rep.pool)
+	if err == nil || !unreachable(ctx, err) {
+		return err
+	}
+
+	if rep.healthy.CompareAndSwap(true, false) {
+		r.logger.Warnf("replica %s is down, reading from the primary: %v", rep.name, err)
+	}
+	return fn(r.primary)
+}
+
+// unreachable tells a failed connection, or a server that is shutting down or starting up, from a failed query.
+func unreachable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var (
+		pgErr      *pgconn.PgError
+		connectErr *pgconn.ConnectError
+		netErr     net.Error
+	)
+	switch {
+	case errors.As(err, &pgErr):
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57P")
+	case errors.As(err, &connectErr), errors.As(err, &netErr):
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || pgconn.SafeToRetry(err)
+}
+
+// Check pings every replica and marks it up or down.
+func (r *Router) Check(ctx context.Context, timeout time.Duration) {
+	for _, rep := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := rep.pool.Ping(pingCtx)
+		cancel()
+
+		switch up := err == nil; {
+		case up && rep.healthy.CompareAndSwap(false, true):
+			r.logger.Infof("replica %s is up", rep.name)
+		case !up && rep.healthy.CompareAndSwap(true, false):
+			r.logger.Warnf("replica %s is down: %v", rep.name, err)
+		}
+	}
+}
+
+// Run checks the replicas every period until ctx is done.
+func (r *Router) Run(ctx context.Context, period time.Duration) {
+	if len(r.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Check(ctx, period)
+		}
+	}
+}
+
+// Ping reports an error when no replica is healthy, reads are then served by the primary.
+func (r *Router) Ping(_ context.Context) error {
+	if len(r.replicas) == 0 {
+		return nil
+	}
+	for _, rep := range r.replicas {
+		if rep.healthy.Load() {
+			return nil
+		}
+	}
+	return errors.New("no healthy replica")
+}
+
+// Close closes the replica pools, the primary is closed by its owner.
+func (r *Router) Close() {
+	for _, rep := range r.replicas {
+		rep.pool.Close()
+	}
+}
@@ -0,0 +1,137 @@
+package snapshot
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/pkg/client"
+)
+
+const maxSnapshotSize = 256 << 20
+
+// Poller loads the snapshot at source, a file path or an http(s) URL, into the table when it is newer.
+// With a login it signs in to the service the URL belongs to, and again when the token expires.
+type Poller struct {
+	source   string
+	key      ed25519.PublicKey
+	table    *Table
+	http     *http.Client
+	login    string
+	password string
+	token    string
+	logger   *logrus.Logger
+}
+
+func NewPoller(source string, key ed25519.PublicKey, table *Table, login, password string,
+	logger *logrus.Logger) *Poller {
+	return &Poller{
+		source:   source,
+		key:      key,
+		table:    table,
+		http:     &http.Client{Timeout: time.Minute},
+		login:    login,
+		password: password,
+		logger:   logger,
+	}
+}
+
+// Run polls every interval until ctx is done. A snapshot that cannot be read or verified is skipped,
+// the table keeps the one it has.
+func (p *Poller) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Poll(ctx); err != nil {
+				p.logger.Error("failed to poll snapshot: ", err)
+			}
+		}
+	}
+}
+
+func (p *Poller) Poll(ctx context.Context) error {
+	data, err := p.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	snap, err := Verify(data, p.key)
+	if err != nil {
+		return err
+	}
+	if p.table.Swap(snap) {
+		p.logger.Infof("loaded snapshot %d with %d banners", snap.Version, len(snap.Banners))
+	}
+	return nil
+}
+
+func (p *Poller) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(p.source, "http://") && !strings.HasPrefix(p.source, "https://") {
+		return os.ReadFile(p.source)
+	}
+
+	resp, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && p.login != "" {
+		resp.Body.Close()
+		if err = p.signIn(ctx); err != nil {
+			return nil, err
+		}
+		if resp, err = p.get(ctx); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", p.source, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSnapshotSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSnapshotSize {
+		return nil, fmt.Errorf("snapshot is larger than %d bytes", maxSnapshotSize)
+	}
+	return data, nil
+}
+
+func (p *Poller) get(ctx context.Context) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.token != "" {
+		req.AddCookie(&http.Cookie{Name: "AccessToken", Value: p.token})
+	}
+	return p.http.Do(req)
+}
+
+func (p *Poller) signIn(ctx context.Context) error {
+	u, err := url.Parse(p.source)
+	if err != nil {
+		return err
+	}
+
+	api := client.New(u.Scheme+"://"+u.Host, p.http)
+	if err = api.SignIn(ctx, p.login, p.password); err != nil {
+		return fmt.Errorf("failed to sign in to %s: %w", u.Host, err)
+	}
+	p.token = api.Token()
+	return nil
+}
@@ -0,0 +1,107 @@
+// Package snapshot signs the banner snapshots an edge node serves from and keeps the latest one in memory.
+package snapshot
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"banner-service/internal/models"
+)
+
+// format is the layout of the signed file, a node refuses a snapshot in a format it does not know.
+const format = 1
+
+var (
+	ErrSignature   = errors.New("snapshot signature does not match")
+	ErrFormat      = errors.New("unknown snapshot format")
+	ErrUnsupported = errors.New("key is not an ed25519 key")
+)
+
+// signed keeps the snapshot as it was signed, the signature covers these bytes.
+type signed struct {
+	Format    int             `json:"format"`
+	Snapshot  json.RawMessage `json:"snapshot"`
+	Signature []byte          `json:"signature"`
+}
+
+func Sign(snap models.BannerSnapshot, key ed25519.PrivateKey) ([]byte, error) {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(signed{Format: format, Snapshot: data, Signature: ed25519.Sign(key, data)})
+}
+
+func Verify(data []byte, key ed25519.PublicKey) (models.BannerSnapshot, error) {
+	var s signed
+	if err := json.Unmarshal(data, &s); err != nil {
+		return models.BannerSnapshot{}, fmt.Errorf("error happened in json.Unmarshal: %w", err)
+	}
+	if s.Format != format {
+		return models.BannerSnapshot{}, fmt.Errorf("%w %d", ErrFormat, s.Format)
+	}
+	if !ed25519.Verify(key, s.Snapshot, s.Signature) {
+		return models.BannerSnapshot{}, ErrSignature
+	}
+
+	var snap models.BannerSnapshot
+	if err := json.Unmarshal(s.Snapshot, &snap); err != nil {
+		return models.BannerSnapshot{}, fmt.Errorf("error happened in json.Unmarshal: %w", err)
+	}
+	return snap, nil
+}
+
+// LoadPrivateKey reads a PKCS #8 "PRIVATE KEY" PEM file, as openssl genpkey -algorithm ed25519 writes it.
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error happened in x509.ParsePKCS8PrivateKey: %w", err)
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return private, nil
+}
+
+// LoadPublicKey reads a PKIX "PUBLIC KEY" PEM file, as openssl pkey -pubout writes it.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error happened in x509.ParsePKIXPublicKey: %w", err)
+	}
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return public, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error happened in os.ReadFile: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+	return block, nil
+}
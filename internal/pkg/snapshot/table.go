@@ -0,0 +1,76 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"banner-service/internal/models"
+)
+
+var ErrNotLoaded = errors.New("no snapshot loaded yet")
+
+type pair struct {
+	tagID, featureID int
+}
+
+type table struct {
+	version int64
+	banners map[pair][]byte
+}
+
+// Table is the snapshot an edge node serves. A newer snapshot replaces the whole table at once, a request
+// never sees banners of two snapshots.
+type Table struct {
+	current atomic.Pointer[table]
+}
+
+func NewTable() *Table {
+	return &Table{}
+}
+
+func (t *Table) Lookup(tagID, featureID int) ([]byte, bool) {
+	current := t.current.Load()
+	if current == nil {
+		return nil, false
+	}
+	content, ok := current.banners[pair{tagID, featureID}]
+	return content, ok
+}
+
+// Version is 0 until a snapshot is loaded.
+func (t *Table) Version() int64 {
+	if current := t.current.Load(); current != nil {
+		return current.version
+	}
+	return 0
+}
+
+// Swap replaces the table with the snapshot unless the table has the same version or a newer one.
+func (t *Table) Swap(snap models.BannerSnapshot) bool {
+	next := &table{
+		version: snap.Version,
+		banners: make(map[pair][]byte, len(snap.Banners)),
+	}
+	for _, b := range snap.Banners {
+		next.banners[pair{b.TagID, b.FeatureID}] = b.Content
+	}
+
+	for {
+		current := t.current.Load()
+		if current != nil && current.version >= next.version {
+			return false
+		}
+		if t.current.CompareAndSwap(current, next) {
+			return true
+		}
+	}
+}
+
+// Ping is the readiness check of an edge node, it fails until a snapshot is loaded.
+func (t *Table) Ping(context.Context) error {
+	if t.current.Load() == nil {
+		return ErrNotLoaded
+	}
+	return nil
+}
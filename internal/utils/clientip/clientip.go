@@ -0,0 +1,14 @@
+package clientip
+
+import (
+	"net"
+	"net/http"
+)
+
+func FromRequest(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,122 @@
+package jwter
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnsupportedKey = errors.New("unsupported private key type")
+	ErrNoSigningKey   = errors.New("no active signing key")
+)
+
+// Key is a signing key with a rotation window: it signs from ActiveFrom and verifies until ExpiresAt.
+type Key struct {
+	ID         string
+	Private    crypto.Signer
+	ActiveFrom time.Time
+	ExpiresAt  time.Time
+}
+
+func LoadKey(id, path string, activeFrom, expiresAt time.Time) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("error happened in os.ReadFile: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("no PEM data in %s", path)
+	}
+
+	var private interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("error happened in x509.Parse: %w", err)
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return Key{}, ErrUnsupportedKey
+	}
+
+	k := Key{ID: id, Private: signer, ActiveFrom: activeFrom, ExpiresAt: expiresAt}
+	if _, err = k.method(); err != nil {
+		return Key{}, err
+	}
+
+	return k, nil
+}
+
+func (k Key) method() (jwt.SigningMethod, error) {
+	switch k.Private.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+func (k Key) expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+func (k Key) jwk() jose.JSONWebKey {
+	method, _ := k.method()
+	return jose.JSONWebKey{
+		Key:       k.Private.Public(),
+		KeyID:     k.ID,
+		Algorithm: method.Alg(),
+		Use:       "sig",
+	}
+}
+
+func (m *Manager) currentKey(now time.Time) (Key, error) {
+	var current Key
+	found := false
+	for _, k := range m.keys {
+		if k.ActiveFrom.After(now) || k.expired(now) {
+			continue
+		}
+		if !found || k.ActiveFrom.After(current.ActiveFrom) {
+			current = k
+			found = true
+		}
+	}
+
+	if !found {
+		return Key{}, ErrNoSigningKey
+	}
+
+	return current, nil
+}
+
+func (m *Manager) JWKS() ([]byte, error) {
+	now := time.Now()
+	set := jose.JSONWebKeySet{Keys: make([]jose.JSONWebKey, 0, len(m.keys))}
+	for _, k := range m.keys {
+		if !k.expired(now) {
+			set.Keys = append(set.Keys, k.jwk())
+		}
+	}
+
+	return json.Marshal(set)
+}
@@ -2,23 +2,48 @@ package jwter
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
 
 	"banner-service/internal/models"
 )
 
+var (
+	ErrTokenMalformed = errors.New("token is malformed")
+	ErrTokenSignature = errors.New("token signature is invalid")
+	ErrTokenExpired   = errors.New("token is expired")
+	ErrTokenNotActive = errors.New("token is not valid yet")
+	ErrTokenIssuer    = errors.New("token has invalid issuer")
+	ErrTokenAudience  = errors.New("token has invalid audience")
+	ErrTokenClaims    = errors.New("token has invalid claims")
+)
+
 type Claims struct {
 	UserID  int  `json:"user_id"`
 	IsAdmin bool `json:"is_admin"`
 	TagID   int  `json:"tag_id"`
-	jwt.StandardClaims
+	jwt.RegisteredClaims
+}
+
+func (c Claims) Validate() error {
+	if c.UserID <= 0 {
+		return errors.New("user_id is missing")
+	}
+	if c.TagID < 0 {
+		return errors.New("tag_id is negative")
+	}
+	return nil
 }
 
 type Manager struct {
 	ttl        time.Duration
 	signingKey string
+	keys       []Key
+	issuer     string
+	audience   string
+	leeway     time.Duration
 }
 
 func New(signingKey string, ttl time.Duration) *Manager {
@@ -28,19 +53,65 @@ func New(signingKey string, ttl time.Duration) *Manager {
 	}
 }
 
+func NewWithKeys(keys []Key, ttl time.Duration) (*Manager, error) {
+	ids := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		if _, ok := ids[k.ID]; ok || k.ID == "" {
+			return nil, fmt.Errorf("key id %q is empty or not unique", k.ID)
+		}
+		ids[k.ID] = struct{}{}
+	}
+
+	return &Manager{
+		ttl:  ttl,
+		keys: keys,
+	}, nil
+}
+
+// WithValidation makes issued tokens carry iss and aud and requires them on parse; leeway absorbs clock skew.
+func (m *Manager) WithValidation(issuer, audience string, leeway time.Duration) *Manager {
+	m.issuer = issuer
+	m.audience = audience
+	m.leeway = leeway
+	return m
+}
+
 func (m *Manager) GenerateJWT(user *models.User) (string, error) {
+	now := time.Now()
 	claims := &Claims{
 		UserID:  user.UserID,
 		IsAdmin: user.IsAdmin,
 		TagID:   user.TagID,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(m.ttl).Unix(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.ttl)),
 		},
 	}
+	if m.audience != "" {
+		claims.Audience = jwt.ClaimStrings{m.audience}
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if len(m.keys) == 0 {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(m.signingKey))
+	}
 
-	tokenString, err := token.SignedString([]byte(m.signingKey))
+	key, err := m.currentKey(now)
+	if err != nil {
+		return "", err
+	}
+
+	method, err := key.method()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.ID
+
+	tokenString, err := token.SignedString(key.Private)
 	if err != nil {
 		return "", err
 	}
@@ -48,23 +119,80 @@ func (m *Manager) GenerateJWT(user *models.User) (string, error) {
 	return tokenString, nil
 }
 
-func (m *Manager) ParseJWT(accessToken string) (map[string]interface{}, error) {
-	claims := jwt.MapClaims{}
+func (m *Manager) ParseJWT(accessToken string) (*Claims, error) {
+	claims := &Claims{}
 
-	token, err := jwt.ParseWithClaims(accessToken, claims, func(token *jwt.Token) (jwtKey interface{}, err error) {
-		return []byte(m.signingKey), err
-	})
+	_, err := jwt.ParseWithClaims(accessToken, claims, m.verificationKey,
+		jwt.WithValidMethods(m.validMethods()),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithAudience(m.audience),
+		jwt.WithLeeway(m.leeway),
+		jwt.WithExpirationRequired(),
+	)
 
 	if err != nil {
-		if errors.Is(err, jwt.ErrSignatureInvalid) {
-			return nil, errors.New("invalid token signature")
+		return nil, classify(err)
+	}
+
+	return claims, nil
+}
+
+func (m *Manager) validMethods() []string {
+	if len(m.keys) == 0 {
+		return []string{jwt.SigningMethodHS256.Alg()}
+	}
+
+	methods := make([]string, 0, len(m.keys))
+	for _, k := range m.keys {
+		if method, err := k.method(); err == nil {
+			methods = append(methods, method.Alg())
 		}
-		return nil, err
 	}
+	return methods
+}
 
-	if !token.Valid {
-		return nil, errors.New("invalid token ")
+func (m *Manager) verificationKey(token *jwt.Token) (interface{}, error) {
+	if len(m.keys) == 0 {
+		return []byte(m.signingKey), nil
 	}
 
-	return claims, nil
+	kid, _ := token.Header["kid"].(string)
+	now := time.Now()
+	for _, k := range m.keys {
+		if k.ID != kid || k.expired(now) {
+			continue
+		}
+
+		method, err := k.method()
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Method.Alg() != method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %v for key %s", token.Header["alg"], kid)
+		}
+
+		return k.Private.Public(), nil
+	}
+
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func classify(err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return fmt.Errorf("%w: %w", ErrTokenMalformed, err)
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return fmt.Errorf("%w: %w", ErrTokenSignature, err)
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ErrTokenExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return ErrTokenNotActive
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return ErrTokenIssuer
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return ErrTokenAudience
+	default:
+		return fmt.Errorf("%w: %w", ErrTokenClaims, err)
+	}
 }
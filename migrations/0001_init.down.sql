@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS sign_in_failure;
+DROP TABLE IF EXISTS "user";
+DROP TABLE IF EXISTS banner_version;
+DROP TABLE IF EXISTS banner_tag_feature;
+DROP TABLE IF EXISTS feature;
+DROP TABLE IF EXISTS tag;
+DROP TABLE IF EXISTS banner;
@@ -38,52 +38,28 @@ CREATE TABLE IF NOT EXISTS banner_version(
 
 CREATE TABLE IF NOT EXISTS "user"(
     user_id    SERIAL PRIMARY KEY,
-    login      VARCHAR(32) UNIQUE  NOT NULL,
+    login      VARCHAR(255) UNIQUE  NOT NULL,
     password   VARCHAR(32) NOT NULL,
     is_admin   BOOLEAN DEFAULT FALSE,
     tag_id     INT,
     FOREIGN KEY (tag_id) REFERENCES tag(tag_id) ON DELETE SET NULL
 );
 
+CREATE TABLE IF NOT EXISTS sign_in_failure(
+    failure_id SERIAL PRIMARY KEY,
+    login      TEXT NOT NULL,
+    ip         TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
 
-CREATE INDEX index_banner
+CREATE INDEX IF NOT EXISTS index_banner
 ON banner(banner_id);
 
-
-CREATE INDEX index_feature_tag
+CREATE INDEX IF NOT EXISTS index_feature_tag
 ON banner_tag_feature(tag_id, feature_id);
 
-INSERT INTO banner (
-    content, is_active, current_version, total_versions
-)
-SELECT
-    ('{"url": "u://banner/' || i::text || '", "title": "title of banner ' ||  i::text  || '"}')::bytea,
-    true
-FROM generate_series(1, 1000000) s(i);
-
-INSERT INTO tag (
-    tag_id
-)
-SELECT
-    i
-FROM generate_series(1, 10000) s(i);
-
-INSERT INTO feature (
-    feature_id
-)
-SELECT
-    i
-FROM generate_series(1, 10000) s(i);
-
-INSERT INTO banner_tag_feature(
-    banner_id, tag_id, feature_id
-)
-SELECT
-    1000 * (tag.num - 1) + feature.num,
-    tag.num,
-    feature.num
-FROM
-    generate_series(1, 1000) AS tag(num),
-    generate_series(1, 1000) AS feature(num);
+CREATE INDEX IF NOT EXISTS index_sign_in_failure_login
+ON sign_in_failure(login, created_at);
 
-INSERT INTO "user" (login, password, is_admin, tag_id) VALUES ('admin', '6789', true, 1);
+CREATE INDEX IF NOT EXISTS index_sign_in_failure_ip
+ON sign_in_failure(ip, created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS index_banner_tag_feature_banner;
+DROP INDEX IF EXISTS index_banner_updated_at;
+DROP INDEX IF EXISTS index_banner_created_at;
@@ -0,0 +1,8 @@
+CREATE INDEX IF NOT EXISTS index_banner_created_at
+ON banner(created_at, banner_id);
+
+CREATE INDEX IF NOT EXISTS index_banner_updated_at
+ON banner(updated_at, banner_id);
+
+CREATE INDEX IF NOT EXISTS index_banner_tag_feature_banner
+ON banner_tag_feature(banner_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS index_banner_search;
+DROP TRIGGER IF EXISTS banner_search_update ON banner;
+ALTER TABLE banner DROP COLUMN IF EXISTS search;
+DROP FUNCTION IF EXISTS banner_search_update();
+DROP FUNCTION IF EXISTS banner_search_vector(BYTEA);
@@ -0,0 +1,34 @@
+-- The title, text and url keys of the content are searchable, weighted in that order.
+CREATE OR REPLACE FUNCTION banner_search_vector(content BYTEA) RETURNS tsvector AS $$
+DECLARE
+    doc jsonb;
+BEGIN
+    doc := convert_from(content, 'UTF8')::jsonb;
+    IF jsonb_typeof(doc) <> 'object' THEN
+        RETURN ''::tsvector;
+    END IF;
+    RETURN setweight(to_tsvector('simple', coalesce(doc ->> 'title', '')), 'A') ||
+           setweight(to_tsvector('simple', coalesce(doc ->> 'text', '')), 'B') ||
+           setweight(to_tsvector('simple', coalesce(doc ->> 'url', '')), 'C');
+EXCEPTION WHEN others THEN
+    RETURN ''::tsvector;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION banner_search_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search := banner_search_vector(NEW.content);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE banner ADD COLUMN IF NOT EXISTS search tsvector;
+
+UPDATE banner SET search = banner_search_vector(content);
+
+CREATE TRIGGER banner_search_update
+BEFORE INSERT OR UPDATE OF content ON banner
+FOR EACH ROW EXECUTE FUNCTION banner_search_update();
+
+CREATE INDEX IF NOT EXISTS index_banner_search
+ON banner USING GIN (search);
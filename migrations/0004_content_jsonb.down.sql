@@ -0,0 +1,23 @@
+DROP INDEX IF EXISTS index_banner_content;
+DROP TRIGGER IF EXISTS banner_search_update ON banner;
+DROP FUNCTION IF EXISTS banner_search_vector(JSONB);
+ALTER TABLE banner_version ALTER COLUMN content TYPE BYTEA USING convert_to(content::text, 'UTF8');
+ALTER TABLE banner ALTER COLUMN content TYPE BYTEA USING convert_to(content::text, 'UTF8');
+CREATE FUNCTION banner_search_vector(content BYTEA) RETURNS tsvector AS $$
+DECLARE
+    doc jsonb;
+BEGIN
+    doc := convert_from(content, 'UTF8')::jsonb;
+    IF jsonb_typeof(doc) <> 'object' THEN
+        RETURN ''::tsvector;
+    END IF;
+    RETURN setweight(to_tsvector('simple', coalesce(doc ->> 'title', '')), 'A') ||
+           setweight(to_tsvector('simple', coalesce(doc ->> 'text', '')), 'B') ||
+           setweight(to_tsvector('simple', coalesce(doc ->> 'url', '')), 'C');
+EXCEPTION WHEN others THEN
+    RETURN ''::tsvector;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+CREATE TRIGGER banner_search_update
+BEFORE INSERT OR UPDATE OF content ON banner
+FOR EACH ROW EXECUTE FUNCTION banner_search_update();
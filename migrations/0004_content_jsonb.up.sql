@@ -0,0 +1,49 @@
+-- Every content must be valid JSON before the type changes, the migration fails with the rows to fix.
+CREATE FUNCTION pg_temp.is_json(content BYTEA) RETURNS BOOLEAN AS $$
+BEGIN
+    PERFORM convert_from(content, 'UTF8')::jsonb;
+    RETURN TRUE;
+EXCEPTION WHEN others THEN
+    RETURN FALSE;
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+    invalid TEXT;
+BEGIN
+    SELECT string_agg(row_id, ', ') INTO invalid FROM (
+        SELECT 'banner ' || banner_id AS row_id FROM banner WHERE NOT pg_temp.is_json(content)
+        UNION ALL
+        SELECT 'banner_version ' || banner_id || ' v' || "version" FROM banner_version
+        WHERE NOT pg_temp.is_json(content)
+    ) t;
+    IF invalid IS NOT NULL THEN
+        RAISE EXCEPTION 'content is not valid JSON in %', invalid;
+    END IF;
+END;
+$$;
+
+DROP FUNCTION pg_temp.is_json(BYTEA);
+
+DROP TRIGGER IF EXISTS banner_search_update ON banner;
+DROP FUNCTION IF EXISTS banner_search_vector(BYTEA);
+
+ALTER TABLE banner ALTER COLUMN content TYPE JSONB USING convert_from(content, 'UTF8')::jsonb;
+ALTER TABLE banner_version ALTER COLUMN content TYPE JSONB USING convert_from(content, 'UTF8')::jsonb;
+
+CREATE FUNCTION banner_search_vector(content JSONB) RETURNS tsvector AS $$
+    SELECT CASE WHEN jsonb_typeof(content) = 'object' THEN
+        setweight(to_tsvector('simple', coalesce(content ->> 'title', '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(content ->> 'text', '')), 'B') ||
+        setweight(to_tsvector('simple', coalesce(content ->> 'url', '')), 'C')
+    ELSE ''::tsvector END;
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE TRIGGER banner_search_update
+BEFORE INSERT OR UPDATE OF content ON banner
+FOR EACH ROW EXECUTE FUNCTION banner_search_update();
+
+-- Supports the content filters of the banner list: @> for values, @? for keys.
+CREATE INDEX IF NOT EXISTS index_banner_content
+ON banner USING GIN (content);
@@ -0,0 +1,7 @@
+// Package migrations embeds the versioned schema migrations, named <version>_<name>.up.sql and .down.sql.
+package migrations
+
+import "embed"
+
+//go:embed [0-9]*.sql
+var FS embed.FS
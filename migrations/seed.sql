@@ -0,0 +1,35 @@
+-- Demo data for local environments, applied by `make seed` after the migrations.
+INSERT INTO banner (
+    content, is_active
+)
+SELECT
+    ('{"url": "u://banner/' || i::text || '", "title": "title of banner ' ||  i::text  || '"}')::jsonb,
+    true
+FROM generate_series(1, 1000000) s(i);
+
+INSERT INTO tag (
+    tag_id
+)
+SELECT
+    i
+FROM generate_series(1, 10000) s(i);
+
+INSERT INTO feature (
+    feature_id
+)
+SELECT
+    i
+FROM generate_series(1, 10000) s(i);
+
+INSERT INTO banner_tag_feature(
+    banner_id, tag_id, feature_id
+)
+SELECT
+    1000 * (tag.num - 1) + feature.num,
+    tag.num,
+    feature.num
+FROM
+    generate_series(1, 1000) AS tag(num),
+    generate_series(1, 1000) AS feature(num);
+
+INSERT INTO "user" (login, password, is_admin, tag_id) VALUES ('admin', '6789', true, 1);
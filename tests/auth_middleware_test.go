@@ -0,0 +1,119 @@
+package tests_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/pkg/middleware"
+	"banner-service/internal/utils/jwter"
+)
+
+func Test_authMiddlewareClaims(t *testing.T) {
+	const secret = "secret"
+	now := time.Now()
+
+	tokenManager := jwter.New(secret, time.Hour).WithValidation("banner-service", "banner-service", time.Minute)
+	mw := middleware.New(logrus.New(), tokenManager)
+
+	sign := func(method jwt.SigningMethod, claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatalf("error signing token: %v", err)
+		}
+		return token
+	}
+
+	valid := func(overrides jwt.MapClaims) jwt.MapClaims {
+		claims := jwt.MapClaims{
+			"user_id":  1,
+			"is_admin": true,
+			"tag_id":   1,
+			"iss":      "banner-service",
+			"aud":      "banner-service",
+			"exp":      now.Add(time.Hour).Unix(),
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(claims, k)
+				continue
+			}
+			claims[k] = v
+		}
+		return claims
+	}
+
+	tests := []struct {
+		Name         string
+		Token        string
+		ExpectedCode int
+	}{
+		{
+			Name:         "Valid token",
+			Token:        sign(jwt.SigningMethodHS256, valid(nil)),
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "Missing user id",
+			Token:        sign(jwt.SigningMethodHS256, valid(jwt.MapClaims{"user_id": nil})),
+			ExpectedCode: http.StatusUnauthorized,
+		},
+		{
+			Name:         "Wrong claim type",
+			Token:        sign(jwt.SigningMethodHS256, valid(jwt.MapClaims{"is_admin": "yes"})),
+			ExpectedCode: http.StatusUnauthorized,
+		},
+		{
+			Name:         "Missing expiration",
+			Token:        sign(jwt.SigningMethodHS256, valid(jwt.MapClaims{"exp": nil})),
+			ExpectedCode: http.StatusUnauthorized,
+		},
+		{
+			Name:         "Expired within leeway",
+			Token:        sign(jwt.SigningMethodHS256, valid(jwt.MapClaims{"exp": now.Add(-30 * time.Second).Unix()})),
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "Expired",
+			Token:        sign(jwt.SigningMethodHS256, valid(jwt.MapClaims{"exp": now.Add(-time.Hour).Unix()})),
+			ExpectedCode: http.StatusUnauthorized,
+		},
+		{
+			Name:         "Wrong issuer",
+			Token:        sign(jwt.SigningMethodHS256, valid(jwt.MapClaims{"iss": "someone-else"})),
+			ExpectedCode: http.StatusUnauthorized,
+		},
+		{
+			Name:         "Wrong audience",
+			Token:        sign(jwt.SigningMethodHS256, valid(jwt.MapClaims{"aud": "someone-else"})),
+			ExpectedCode: http.StatusUnauthorized,
+		},
+		{
+			Name:         "Unexpected algorithm",
+			Token:        sign(jwt.SigningMethodHS512, valid(nil)),
+			ExpectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/banner", nil)
+			req.AddCookie(&http.Cookie{Name: "AccessToken", Value: test.Token})
+
+			w := httptest.NewRecorder()
+			mw.Auth(true, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})).ServeHTTP(w, req)
+
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Errorf("expected status code: %v, got status code: %v", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
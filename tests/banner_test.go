@@ -118,7 +118,7 @@ func Test_getUserBanner(t *testing.T) {
 
 			if test.ExpectedContent != nil {
 				resp, _ := io.ReadAll(w.Body)
-				if d := cmp.Diff(test.ExpectedContent, resp); d != "" {
+				if d := jsonDiff(test.ExpectedContent, resp); d != "" {
 					t.Errorf("unexpected difference in response body:\n%v", d)
 				}
 			}
@@ -180,6 +180,17 @@ func Test_getFilterBanner(t *testing.T) {
 			ExpectedResp: []models.Banner{expectedBanners[0]},
 			ExpectedCode: http.StatusOK,
 		},
+		{
+			Name:         "Get Banners by tag",
+			TagID:        expectedBanners[1].TagIDs[0],
+			ExpectedResp: []models.Banner{expectedBanners[1]},
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "Get all Banners",
+			ExpectedResp: expectedBanners,
+			ExpectedCode: http.StatusOK,
+		},
 	}
 
 	for _, test := range tests {
@@ -305,7 +316,7 @@ func Test_addBanner(t *testing.T) {
 				t.Errorf("error to get banner")
 			}
 
-			if d := cmp.Diff(test.RequestBody.Content, expectedBanner.Content); d != "" {
+			if d := jsonDiff(test.RequestBody.Content, expectedBanner.Content); d != "" {
 				t.Errorf("unexpected difference in response body:\n%v", d)
 			}
 
@@ -401,7 +412,7 @@ func Test_updateBanner(t *testing.T) {
 				t.Errorf("error to get banner")
 			}
 
-			if d := cmp.Diff(test.RequestBody.Content, banner.Content); d != "" {
+			if d := jsonDiff(test.RequestBody.Content, banner.Content); d != "" {
 				t.Errorf("unexpected difference in response body:\n%v", d)
 			}
 		}
@@ -487,3 +498,19 @@ func Test_deleteBanner(t *testing.T) {
 		t.Run(test.Name, fn)
 	}
 }
+
+// jsonDiff compares JSON documents by value, the database stores content as jsonb and normalizes it.
+func jsonDiff(expected, actual []byte) string {
+	if len(expected) == 0 || len(actual) == 0 {
+		return cmp.Diff(expected, actual)
+	}
+
+	var e, a interface{}
+	if err := json.Unmarshal(expected, &e); err != nil {
+		return err.Error()
+	}
+	if err := json.Unmarshal(actual, &a); err != nil {
+		return err.Error()
+	}
+	return cmp.Diff(e, a)
+}
@@ -0,0 +1,210 @@
+package tests_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/banner"
+	bannerHandler "banner-service/internal/pkg/banner/http"
+	bannerRepository "banner-service/internal/pkg/banner/repository"
+	bannerService "banner-service/internal/pkg/banner/service"
+	"banner-service/tests/db"
+)
+
+type batchRepository struct {
+	banner.BannerRepository
+	created []models.BannerPayload
+}
+
+func (r *batchRepository) CreateBanners(_ context.Context, banners []models.BannerPayload,
+	_ bool) ([]models.BatchResult, error) {
+	r.created = banners
+	results := make([]models.BatchResult, len(banners))
+	for i := range banners {
+		results[i] = models.BatchResult{Index: i, BannerID: 100 + i}
+	}
+	return results, nil
+}
+
+func Test_addBannersValidation(t *testing.T) {
+	const body = `[
+		{"tag_ids":[1,2],"feature_id":1,"content":{"title":"a"},"is_active":true},
+		{"tag_ids":[3],"feature_id":1,"content":{"title":"b"}},
+		{"tag_ids":[2],"feature_id":1,"content":{"title":"c"},"is_active":true},
+		{"tag_ids":[4,4],"feature_id":1,"content":{},"is_active":true},
+		{"tag_ids":[5],"feature_id":2,"content":{},"is_active":false}
+	]`
+
+	tests := []struct {
+		name          string
+		mode          string
+		body          string
+		expectedCode  int
+		expectedSent  int
+		expectedIDs   map[int]int
+		expectedFails []int
+	}{
+		{
+			name:          "atomic does not write when an item is invalid",
+			body:          body,
+			expectedCode:  http.StatusUnprocessableEntity,
+			expectedIDs:   map[int]int{},
+			expectedFails: []int{1, 2, 3},
+		},
+		{
+			name:          "best effort writes valid items",
+			mode:          "best_effort",
+			body:          body,
+			expectedCode:  http.StatusUnprocessableEntity,
+			expectedSent:  2,
+			expectedIDs:   map[int]int{0: 100, 4: 101},
+			expectedFails: []int{1, 2, 3},
+		},
+		{
+			name:         "all valid",
+			body:         `[{"tag_ids":[1],"feature_id":1,"content":{},"is_active":true}]`,
+			expectedCode: http.StatusCreated,
+			expectedSent: 1,
+			expectedIDs:  map[int]int{0: 100},
+		},
+		{
+			name:         "empty batch",
+			body:         `[]`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "not an array",
+			body:         `{"tag_ids":[1]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown mode",
+			mode:         "some",
+			body:         body,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &batchRepository{}
+			bh := bannerHandler.NewBannerHandler(bannerService.NewBannerService(repo, nil), logrus.New())
+
+			target := "/api/banner/batch"
+			if tc.mode != "" {
+				target += "?mode=" + tc.mode
+			}
+			w := httptest.NewRecorder()
+			bh.AddBanners(w, httptest.NewRequest(http.MethodPost, target, strings.NewReader(tc.body)))
+
+			if e, a := tc.expectedCode, w.Code; e != a {
+				t.Fatalf("expected status code: %v, got status code: %v: %s", e, a, w.Body.String())
+			}
+			if tc.expectedCode == http.StatusBadRequest {
+				return
+			}
+			if e, a := tc.expectedSent, len(repo.created); e != a {
+				t.Errorf("expected %d banners sent to the repository, got %d", e, a)
+			}
+
+			var report models.BatchReport
+			if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatal(err)
+			}
+			if e, a := len(tc.expectedIDs), report.Created; e != a {
+				t.Errorf("expected %d created, got %d", e, a)
+			}
+			if e, a := len(tc.expectedFails), report.Failed; e != a {
+				t.Errorf("expected %d failed, got %d: %+v", e, a, report.Results)
+			}
+			for _, i := range tc.expectedFails {
+				if report.Results[i].Error == "" {
+					t.Errorf("expected an error for item %d", i)
+				}
+			}
+			for i, id := range tc.expectedIDs {
+				if e, a := id, report.Results[i].BannerID; e != a {
+					t.Errorf("expected banner id %d for item %d, got %d", e, i, a)
+				}
+			}
+		})
+	}
+}
+
+func Test_addBanners(t *testing.T) {
+	testDB, err := db.Open()
+	if err != nil {
+		t.Fatalf("error to connect: %v", err)
+	}
+	defer func() {
+		if err := db.Truncate(testDB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+		testDB.Close()
+	}()
+
+	if _, err = db.SeedFeatures(testDB); err != nil {
+		t.Fatalf("error seeding features: %v", err)
+	}
+	if _, err = db.SeedTags(testDB); err != nil {
+		t.Fatalf("error seeding tags: %v", err)
+	}
+	if _, err = db.SeedBanners(testDB); err != nil {
+		t.Fatalf("error seeding banners: %v", err)
+	}
+
+	banners := []models.BannerPayload{
+		{TagIDs: []int{7, 8}, FeatureID: 7, Content: []byte(`{"title":"a"}`), IsActive: models.NullBool{IsTrue: true}},
+		{TagIDs: []int{1}, FeatureID: 1, Content: []byte(`{"title":"taken"}`)},
+		{TagIDs: []int{9}, FeatureID: 42, Content: []byte(`{"title":"unknown feature"}`)},
+		{TagIDs: []int{9}, FeatureID: 8, Content: []byte(`{"title":"b"}`)},
+	}
+	countBanners := func() int {
+		var n int
+		if err := testDB.QueryRow(context.Background(), `SELECT count(*) FROM banner`).Scan(&n); err != nil {
+			t.Fatalf("error counting banners: %v", err)
+		}
+		return n
+	}
+
+	br := bannerRepository.NewBannerRepository(testDB)
+	before := countBanners()
+
+	results, err := br.CreateBanners(context.Background(), banners, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[1].Error == "" || results[2].Error == "" {
+		t.Errorf("expected errors for the taken pair and the unknown feature, got %+v", results)
+	}
+	if e, a := before, countBanners(); e != a {
+		t.Errorf("expected atomic batch to write nothing, banners went from %d to %d", e, a)
+	}
+
+	results, err = br.CreateBanners(context.Background(), banners, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := before+2, countBanners(); e != a {
+		t.Errorf("expected %d banners, got %d", e, a)
+	}
+	for _, i := range []int{0, 3} {
+		if results[i].BannerID == 0 || results[i].Error != "" {
+			t.Errorf("expected item %d to be created, got %+v", i, results[i])
+		}
+	}
+
+	var n int
+	err = testDB.QueryRow(context.Background(), `SELECT count(*) FROM banner_tag_feature WHERE banner_id=$1`,
+		results[0].BannerID).Scan(&n)
+	if err != nil || n != 2 {
+		t.Errorf("expected 2 tags for the first banner, got %d: %v", n, err)
+	}
+}
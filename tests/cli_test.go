@@ -0,0 +1,162 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"banner-service/internal/cli"
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/config"
+)
+
+func newStubAPI(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/sign_in", func(w http.ResponseWriter, r *http.Request) {
+		var u models.User
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil || u.Login != "admin" || u.Password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "AccessToken", Value: "token"})
+	})
+	mux.HandleFunc("/api/banner", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("AccessToken"); err != nil || c.Value != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode([]models.Banner{
+				{BannerID: 1, TagIDs: []int{1, 2}, FeatureID: 3, Content: json.RawMessage(`{"title":"t"}`), IsActive: true},
+			})
+		case http.MethodPost:
+			var p map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			if p["feature_id"] != float64(3) || p["is_active"] != true {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"bad payload"}`))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"banner_id":42}`))
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func runCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	var out bytes.Buffer
+	cmd := cli.NewRootCommand(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func Test_cliBannerList(t *testing.T) {
+	srv := newStubAPI(t)
+	t.Setenv("BANNERCTL_PROFILES", filepath.Join(t.TempDir(), "missing.yaml"))
+	t.Setenv("BANNERCTL_URL", srv.URL)
+	t.Setenv("BANNERCTL_LOGIN", "admin")
+	t.Setenv("BANNERCTL_PASSWORD", "secret")
+
+	out, err := runCLI(t, "banner", "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "ID") || !strings.Contains(out, "1,2") || !strings.Contains(out, `{"title":"t"}`) {
+		t.Errorf("unexpected table output:\n%s", out)
+	}
+
+	out, err = runCLI(t, "banner", "list", "-o", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var banners []models.Banner
+	if err = json.Unmarshal([]byte(out), &banners); err != nil || len(banners) != 1 || banners[0].FeatureID != 3 {
+		t.Errorf("unexpected json output: %s (%v)", out, err)
+	}
+}
+
+func Test_cliBannerCreate(t *testing.T) {
+	srv := newStubAPI(t)
+	t.Setenv("BANNERCTL_PROFILES", filepath.Join(t.TempDir(), "missing.yaml"))
+	t.Setenv("BANNERCTL_TOKEN", "token")
+
+	out, err := runCLI(t, "--url", srv.URL, "banner", "create", "--tag", "1,2", "--feature", "3",
+		"--content", `{"title":"t"}`, "-o", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"banner_id": 42`) {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	if _, err = runCLI(t, "--url", srv.URL, "banner", "create", "--tag", "1", "--feature", "3"); err == nil {
+		t.Errorf("expected error without content")
+	}
+}
+
+func Test_cliSignInFailure(t *testing.T) {
+	srv := newStubAPI(t)
+	t.Setenv("BANNERCTL_PROFILES", filepath.Join(t.TempDir(), "missing.yaml"))
+	t.Setenv("BANNERCTL_URL", srv.URL)
+	t.Setenv("BANNERCTL_LOGIN", "admin")
+	t.Setenv("BANNERCTL_PASSWORD", "wrong")
+
+	_, err := runCLI(t, "banner", "list")
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected unauthorized error, got: %v", err)
+	}
+}
+
+func Test_cliProfile(t *testing.T) {
+	srv := newStubAPI(t)
+
+	profiles := filepath.Join(t.TempDir(), "profiles.yaml")
+	content := "current: prod\nprofiles:\n  prod:\n    url: " + srv.URL + "\n    login: admin\n    password: secret\n" +
+		"  staging:\n    url: http://127.0.0.1:1\n"
+	if err := os.WriteFile(profiles, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BANNERCTL_PROFILES", profiles)
+
+	if _, err := runCLI(t, "banner", "list"); err != nil {
+		t.Errorf("expected current profile to sign in, got: %v", err)
+	}
+	if _, err := runCLI(t, "--profile", "unknown", "banner", "list"); err == nil {
+		t.Errorf("expected error for unknown profile")
+	}
+}
+
+func Test_cliDatabaseConfig(t *testing.T) {
+	t.Setenv("BANNERCTL_PROFILES", filepath.Join(t.TempDir(), "none.yaml"))
+
+	// Only the postgres section is set, no JWT secret, Redis or storage settings.
+	path := writeConfig(t, "postgres:\n  dbName: \"bannerDB\"\n  dbHost: \"127.0.0.1\"\n  dbPort: 1\n  dbUser: \"root\"\n")
+	t.Setenv("BANNERCTL_CONFIG", path)
+
+	_, err := runCLI(t, "tag", "list")
+	if err == nil || !strings.Contains(err.Error(), "cannot connect to the database") {
+		t.Errorf("expected the ping to fail before any query, got: %v", err)
+	}
+
+	t.Setenv("BANNERCTL_CONFIG", writeConfig(t, "postgres:\n  dbName: \"bannerDB\"\n"))
+	var invalid *config.ValidationError
+	if _, err = runCLI(t, "tag", "list"); !errors.As(err, &invalid) || len(invalid.Problems) != 2 {
+		t.Errorf("expected dbHost and dbUser to be reported, got: %v", err)
+	}
+}
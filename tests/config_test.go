@@ -0,0 +1,138 @@
+package tests_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"banner-service/internal/pkg/config"
+	"banner-service/internal/pkg/ratelimit"
+)
+
+const testConfig = `
+http_server:
+  address: "localhost:8080"
+postgres:
+  dbName: "bannerDB"
+  dbHost: "postgres"
+  dbUser: "root"
+redis:
+  address: "cache:6379"
+rate_limit:
+  default:
+    rate: 50
+    burst: 100
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_configEnvOverrides(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "jwt")
+	if err := os.WriteFile(secret, []byte(strings.Repeat("s", 32)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("JWT_SECRET_FILE", secret)
+	t.Setenv("DB_HOST", "replica")
+	t.Setenv("REDIS_TTL", "1m")
+	t.Setenv("RATE_LIMIT_DEFAULT_BURST", "7")
+	t.Setenv("RATE_LIMIT_ROUTES", "user_banner:10/20,sign_in:0.5/5")
+
+	cfg, err := config.Load(writeConfig(t, testConfig), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := strings.Repeat("s", 32), cfg.JWTSecret; e != a {
+		t.Errorf("expected jwt secret from file: %v, got: %v", e, a)
+	}
+	if e, a := "replica", cfg.DBHost; e != a {
+		t.Errorf("expected db host: %v, got: %v", e, a)
+	}
+	if e, a := time.Minute, cfg.RedisTTL; e != a {
+		t.Errorf("expected redis ttl: %v, got: %v", e, a)
+	}
+	if e, a := (ratelimit.Limit{Rate: 50, Burst: 7}), cfg.RateLimitDefault; e != a {
+		t.Errorf("expected default limit: %v, got: %v", e, a)
+	}
+	if e, a := (ratelimit.Limit{Rate: 0.5, Burst: 5}), cfg.RateLimitRoutes["sign_in"]; e != a {
+		t.Errorf("expected sign_in limit: %v, got: %v", e, a)
+	}
+	if e, a := 10*time.Second, cfg.ReadHeaderTimeout; e != a {
+		t.Errorf("expected default read header timeout: %v, got: %v", e, a)
+	}
+	if e, a := 5432, cfg.DBPort; e != a {
+		t.Errorf("expected default db port: %v, got: %v", e, a)
+	}
+}
+
+func Test_configMissingFile(t *testing.T) {
+	t.Setenv("HTTP_ADDRESS", "localhost:8080")
+	t.Setenv("JWT_SECRET", strings.Repeat("s", 32))
+	t.Setenv("DB_HOST", "postgres")
+	t.Setenv("DB_NAME", "bannerDB")
+	t.Setenv("DB_USER", "root")
+	t.Setenv("REDIS_ADDRESS", "cache:6379")
+
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	if _, err := config.Load(missing, false); err != nil {
+		t.Errorf("expected config from environment, got err: %v", err)
+	}
+	if _, err := config.Load(missing, true); err == nil {
+		t.Errorf("expected error for missing required config file")
+	}
+}
+
+func Test_configValidation(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "loud")
+	t.Setenv("TRACING_EXPORTER", "otlp")
+	t.Setenv("DB_MIN_CONNS", "50")
+	t.Setenv("DB_REPLICAS", "replica-1:5432,replica-2")
+
+	_, err := config.Load(writeConfig(t, testConfig), true)
+
+	var verr *config.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected validation error, got: %v", err)
+	}
+
+	expected := []string{"JWT_SECRET", "DB_MIN_CONNS", "DB_REPLICAS", "LOG_LEVEL", "TRACING_ENDPOINT"}
+	if e, a := len(expected), len(verr.Problems); e != a {
+		t.Errorf("expected %d problems, got %d: %v", e, a, verr.Problems)
+	}
+	for _, e := range expected {
+		if !strings.Contains(err.Error(), e) {
+			t.Errorf("expected problem mentioning %s in %q", e, err)
+		}
+	}
+}
+
+func Test_configMemoryStorage(t *testing.T) {
+	t.Setenv("JWT_SECRET", strings.Repeat("s", 32))
+	t.Setenv("STORAGE_BACKEND", "memory")
+	t.Setenv("DB_MIN_CONNS", "50")
+
+	cfg, err := config.Load(writeConfig(t, testConfig), true)
+	if err != nil {
+		t.Fatalf("expected the postgres settings to be ignored, got: %v", err)
+	}
+	if e, a := 1000, cfg.StorageTags; e != a {
+		t.Errorf("expected default tags: %v, got: %v", e, a)
+	}
+
+	t.Setenv("STORAGE_BACKEND", "sqlite")
+	if _, err = config.Load(writeConfig(t, testConfig), true); err == nil ||
+		!strings.Contains(err.Error(), "STORAGE_BACKEND") {
+		t.Errorf("expected a STORAGE_BACKEND problem, got: %v", err)
+	}
+}
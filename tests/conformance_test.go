@@ -0,0 +1,486 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/auth"
+	authRepository "banner-service/internal/pkg/auth/repository"
+	"banner-service/internal/pkg/banner"
+	bannerRepository "banner-service/internal/pkg/banner/repository"
+	"banner-service/internal/pkg/memstore"
+	"banner-service/tests/db"
+)
+
+// storageBackend opens empty repositories with tags and features 1 to 10.
+type storageBackend struct {
+	name string
+	open func(t *testing.T) (banner.BannerRepository, auth.Repository)
+}
+
+var storageBackends = []storageBackend{
+	{
+		name: "memory",
+		open: func(*testing.T) (banner.BannerRepository, auth.Repository) {
+			store := memstore.New()
+			store.AddTags(1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+			store.AddFeatures(1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+			return bannerRepository.NewMemoryBannerRepository(store), authRepository.NewMemoryAuthRepository(store)
+		},
+	},
+	{
+		name: "postgres",
+		open: func(t *testing.T) (banner.BannerRepository, auth.Repository) {
+			testDB, err := db.Open()
+			if err != nil {
+				t.Fatalf("error to connect: %v", err)
+			}
+			t.Cleanup(func() {
+				if err := db.Truncate(testDB); err != nil {
+					t.Errorf("error truncating test database tables: %v", err)
+				}
+				testDB.Close()
+			})
+
+			if err = db.Truncate(testDB); err != nil {
+				t.Fatalf("error truncating test database tables: %v", err)
+			}
+			if _, err = db.SeedFeatures(testDB); err != nil {
+				t.Fatalf("error seeding features: %v", err)
+			}
+			if _, err = db.SeedTags(testDB); err != nil {
+				t.Fatalf("error seeding tags: %v", err)
+			}
+			return bannerRepository.NewBannerRepository(testDB), authRepository.NewAuthRepository(testDB)
+		},
+	},
+}
+
+var (
+	activeBanner   = models.NullBool{IsTrue: true, HasValue: true}
+	inactiveBanner = models.NullBool{IsTrue: false, HasValue: true}
+)
+
+func Test_bannerRepositoryConformance(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, br banner.BannerRepository)
+	}{
+		{name: "create and read", run: conformCreateRead},
+		{name: "inactive banner is hidden from users", run: conformInactive},
+		{name: "tag and feature are unique", run: conformUnique},
+		{name: "unknown tag", run: conformUnknownTag},
+		{name: "versions", run: conformVersions},
+		{name: "delete", run: conformDelete},
+		{name: "list", run: conformList},
+		{name: "batch", run: conformBatch},
+		{name: "import", run: conformImport},
+		{name: "search highlights are escaped", run: conformSearchEscaped},
+	}
+
+	for _, backend := range storageBackends {
+		for _, tt := range tests {
+			t.Run(backend.name+"/"+tt.name, func(t *testing.T) {
+				br, _ := backend.open(t)
+				tt.run(t, br)
+			})
+		}
+	}
+}
+
+func createConformBanner(t *testing.T, br banner.BannerRepository, tagIDs []int, featureID int,
+	content string) int {
+	t.Helper()
+
+	id, err := br.CreateBanner(context.Background(), &models.BannerPayload{
+		TagIDs: tagIDs, FeatureID: featureID, Content: []byte(content), IsActive: activeBanner,
+	})
+	if err != nil {
+		t.Fatalf("error creating banner: %v", err)
+	}
+	return id
+}
+
+func expectContent(t *testing.T, expected string, actual []byte, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := jsonDiff([]byte(expected), actual); diff != "" {
+		t.Errorf("unexpected content: %s", diff)
+	}
+}
+
+func conformCreateRead(t *testing.T, br banner.BannerRepository) {
+	ctx := context.Background()
+	id := createConformBanner(t, br, []int{1, 2}, 1, `{"title": "a"}`)
+
+	content, err := br.ReadUserBanner(ctx, 2, 1)
+	expectContent(t, `{"title":"a"}`, content, err)
+	content, err = br.ReadBanner(ctx, 1, 1)
+	expectContent(t, `{"title":"a"}`, content, err)
+
+	if _, err = br.ReadBanner(ctx, 3, 1); !errors.Is(err, bannerRepository.ErrBannerNotFound) {
+		t.Errorf("expected ErrBannerNotFound for another tag, got %v", err)
+	}
+
+	current, err := br.ReadCurrentBannerByID(ctx, id)
+	if err != nil {
+		t.Fatalf("error reading current version: %v", err)
+	}
+	if current.Version != 1 {
+		t.Errorf("expected version 1, got %d", current.Version)
+	}
+	if _, err = br.ReadCurrentBannerByID(ctx, id+100); !errors.Is(err, bannerRepository.ErrBannerNotFound) {
+		t.Errorf("expected ErrBannerNotFound for an unknown id, got %v", err)
+	}
+}
+
+func conformInactive(t *testing.T, br banner.BannerRepository) {
+	ctx := context.Background()
+	_, err := br.CreateBanner(ctx, &models.BannerPayload{
+		TagIDs: []int{1}, FeatureID: 1, Content: []byte(`{}`), IsActive: inactiveBanner,
+	})
+	if err != nil {
+		t.Fatalf("error creating banner: %v", err)
+	}
+
+	if _, err = br.ReadUserBanner(ctx, 1, 1); !errors.Is(err, bannerRepository.ErrBannerNotFound) {
+		t.Errorf("expected ErrBannerNotFound for a user, got %v", err)
+	}
+	content, err := br.ReadBanner(ctx, 1, 1)
+	expectContent(t, `{}`, content, err)
+
+	count, err := br.CountActiveBanners(ctx)
+	if err != nil || count != 0 {
+		t.Errorf("expected no active banners, got %d, %v", count, err)
+	}
+}
+
+func conformUnique(t *testing.T, br banner.BannerRepository) {
+	ctx := context.Background()
+	first := createConformBanner(t, br, []int{1}, 1, `{"n":1}`)
+
+	_, err := br.CreateBanner(ctx, &models.BannerPayload{
+		TagIDs: []int{3, 1}, FeatureID: 1, Content: []byte(`{"n":2}`), IsActive: activeBanner,
+	})
+	if err == nil {
+		t.Fatal("expected an error for a taken tag and feature")
+	}
+	if _, err = br.ReadBanner(ctx, 3, 1); !errors.Is(err, bannerRepository.ErrBannerNotFound) {
+		t.Errorf("expected the failed banner to be rolled back, got %v", err)
+	}
+
+	second := createConformBanner(t, br, []int{1}, 2, `{"n":3}`)
+	err = br.UpdateBanner(ctx, second, &models.BannerPayload{FeatureID: 1})
+	if err == nil {
+		t.Fatal("expected an error for an update to a taken tag and feature")
+	}
+	content, err := br.ReadBanner(ctx, 1, 2)
+	expectContent(t, `{"n":3}`, content, err)
+
+	if err = br.UpdateBanner(ctx, first, &models.BannerPayload{TagIDs: []int{4, 5}}); err != nil {
+		t.Fatalf("error updating tags: %v", err)
+	}
+	content, err = br.ReadBanner(ctx, 5, 1)
+	expectContent(t, `{"n":1}`, content, err)
+	if _, err = br.ReadBanner(ctx, 1, 1); !errors.Is(err, bannerRepository.ErrBannerNotFound) {
+		t.Errorf("expected the old tag to be released, got %v", err)
+	}
+	createConformBanner(t, br, []int{1}, 1, `{"n":4}`)
+}
+
+func conformUnknownTag(t *testing.T, br banner.BannerRepository) {
+	ctx := context.Background()
+	_, err := br.CreateBanner(ctx, &models.BannerPayload{
+		TagIDs: []int{99}, FeatureID: 1, Content: []byte(`{}`), IsActive: activeBanner,
+	})
+	if err == nil {
+		t.Fatal("expected an error for an unknown tag")
+	}
+
+	_, err = br.CreateBanner(ctx, &models.BannerPayload{
+		TagIDs: []int{1}, FeatureID: 99, Content: []byte(`{}`), IsActive: activeBanner,
+	})
+	if err == nil {
+		t.Fatal("expected an error for an unknown feature")
+	}
+}
+
+func conformVersions(t *testing.T, br banner.BannerRepository) {
+	ctx := context.Background()
+	id := createConformBanner(t, br, []int{1}, 1, `{"v":1}`)
+
+	for _, content := range []string{`{"v":2}`, `{"v":3}`, `{"v":4}`} {
+		if err := br.UpdateBanner(ctx, id, &models.BannerPayload{Content: []byte(content)}); err != nil {
+			t.Fatalf("error updating banner: %v", err)
+		}
+	}
+
+	current, err := br.ReadCurrentBannerByID(ctx, id)
+	expectContent(t, `{"v":4}`, current.Content, err)
+	if current.Version != 4 {
+		t.Errorf("expected version 4, got %d", current.Version)
+	}
+
+	old, err := br.ReadOldVersions(ctx, id)
+	if err != nil {
+		t.Fatalf("error reading old versions: %v", err)
+	}
+	if len(old) != 2 {
+		t.Fatalf("expected 2 old versions, got %d", len(old))
+	}
+	if last := old[len(old)-1]; last.Version != 3 {
+		t.Errorf("expected the newest old version to be 3, got %d", last.Version)
+	}
+
+	if err = br.UpdateVersionOfBanner(ctx, id, 3); err != nil {
+		t.Fatalf("error changing version: %v", err)
+	}
+	current, err = br.ReadCurrentBannerByID(ctx, id)
+	expectContent(t, `{"v":3}`, current.Content, err)
+	if current.Version != 3 {
+		t.Errorf("expected version 3, got %d", current.Version)
+	}
+	content, err := br.ReadUserBanner(ctx, 1, 1)
+	expectContent(t, `{"v":3}`, content, err)
+
+	if old, err = br.ReadOldVersions(ctx, id); err != nil || len(old) != 1 {
+		t.Errorf("expected 1 old version, got %d, %v", len(old), err)
+	}
+	if err = br.UpdateVersionOfBanner(ctx, id, 3); !errors.Is(err, bannerRepository.ErrBannerNotFound) {
+		t.Errorf("expected ErrBannerNotFound for a version that is not old, got %v", err)
+	}
+}
+
+func conformDelete(t *testing.T, br banner.BannerRepository) {
+	ctx := context.Background()
+	id := createConformBanner(t, br, []int{1, 2}, 1, `{}`)
+
+	if err := br.DeleteBanner(ctx, id+100); !errors.Is(err, bannerRepository.ErrBannerNotFound) {
+		t.Errorf("expected ErrBannerNotFound, got %v", err)
+	}
+	if err := br.DeleteBanner(ctx, id); err != nil {
+		t.Fatalf("error deleting banner: %v", err)
+	}
+	if _, err := br.ReadBanner(ctx, 2, 1); !errors.Is(err, bannerRepository.ErrBannerNotFound) {
+		t.Errorf("expected ErrBannerNotFound after delete, got %v", err)
+	}
+	if err := br.DeleteBanner(ctx, id); !errors.Is(err, bannerRepository.ErrBannerNotFound) {
+		t.Errorf("expected ErrBannerNotFound for a second delete, got %v", err)
+	}
+	createConformBanner(t, br, []int{1, 2}, 1, `{}`)
+}
+
+func conformList(t *testing.T, br banner.BannerRepository) {
+	ctx := context.Background()
+	a := createConformBanner(t, br, []int{1, 2}, 1, `{"title":"a"}`)
+	b := createConformBanner(t, br, []int{1}, 2, `{"title":"b"}`)
+	c, err := br.CreateBanner(ctx, &models.BannerPayload{
+		TagIDs: []int{3}, FeatureID: 2, Content: []byte(`{"title":"c"}`), IsActive: inactiveBanner,
+	})
+	if err != nil {
+		t.Fatalf("error creating banner: %v", err)
+	}
+
+	active, title := true, "b"
+	tests := []struct {
+		name     string
+		filter   models.BannerFilter
+		expected []int
+		total    int
+	}{
+		{name: "all", expected: []int{a, b, c}, total: 3},
+		{name: "tag", filter: models.BannerFilter{TagID: 1}, expected: []int{a, b}, total: 2},
+		{name: "feature", filter: models.BannerFilter{FeatureID: 2}, expected: []int{b, c}, total: 2},
+		{name: "active", filter: models.BannerFilter{IsActive: &active}, expected: []int{a, b}, total: 2},
+		{name: "limit", filter: models.BannerFilter{Limit: 1, Offset: 1}, expected: []int{b}, total: 3},
+		{name: "descending", filter: models.BannerFilter{Desc: true}, expected: []int{c, b, a}, total: 3},
+		{
+			name: "content",
+			filter: models.BannerFilter{
+				Content: []models.ContentFilter{{Path: []string{"title"}, Value: &title}},
+			},
+			expected: []int{b},
+			total:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		page, err := br.ReadFilterBanners(ctx, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: error listing banners: %v", tt.name, err)
+		}
+		ids := make([]int, 0, len(page.Banners))
+		for _, banner := range page.Banners {
+			ids = append(ids, banner.BannerID)
+		}
+		if !equalInts(ids, tt.expected) || page.Total != tt.total {
+			t.Errorf("%s: expected %v of %d, got %v of %d", tt.name, tt.expected, tt.total, ids, page.Total)
+		}
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func conformBatch(t *testing.T, br banner.BannerRepository) {
+	ctx := context.Background()
+	createConformBanner(t, br, []int{1}, 1, `{}`)
+
+	batch := []models.BannerPayload{
+		{TagIDs: []int{2}, FeatureID: 1, Content: []byte(`{}`), IsActive: activeBanner},
+		{TagIDs: []int{1}, FeatureID: 1, Content: []byte(`{}`), IsActive: activeBanner},
+		{TagIDs: []int{99}, FeatureID: 1, Content: []byte(`{}`), IsActive: activeBanner},
+	}
+
+	results, err := br.CreateBanners(ctx, batch, true)
+	if err != nil {
+		t.Fatalf("error creating atomic batch: %v", err)
+	}
+	if results[0].BannerID != 0 || results[1].Error == "" || results[2].Error == "" {
+		t.Errorf("expected an atomic batch to fail items 1 and 2 and write nothing, got %+v", results)
+	}
+	if _, err = br.ReadBanner(ctx, 2, 1); !errors.Is(err, bannerRepository.ErrBannerNotFound) {
+		t.Errorf("expected nothing written by the atomic batch, got %v", err)
+	}
+
+	results, err = br.CreateBanners(ctx, batch, false)
+	if err != nil {
+		t.Fatalf("error creating best effort batch: %v", err)
+	}
+	if results[0].BannerID == 0 || results[0].Error != "" || results[1].Error == "" || results[2].Error == "" {
+		t.Errorf("expected a best effort batch to write item 0 only, got %+v", results)
+	}
+	if _, err = br.ReadBanner(ctx, 2, 1); err != nil {
+		t.Errorf("expected item 0 to be written, got %v", err)
+	}
+}
+
+func conformImport(t *testing.T, br banner.BannerRepository) {
+	ctx := context.Background()
+	lines := []models.ImportLine{
+		{Line: 1, Record: models.BannerRecord{Banner: models.Banner{
+			TagIDs: []int{1}, FeatureID: 1, Content: []byte(`{"v":2}`), IsActive: true}, Version: 2,
+			Versions: []models.BannerVersion{{Version: 1, Content: []byte(`{"v":1}`)}}}},
+		{Line: 2, Record: models.BannerRecord{Banner: models.Banner{
+			TagIDs: []int{1}, FeatureID: 1, Content: []byte(`{}`), IsActive: true}}},
+		{Line: 3, Record: models.BannerRecord{Banner: models.Banner{
+			TagIDs: []int{2}, FeatureID: 99, Content: []byte(`{}`), IsActive: true}}},
+	}
+
+	report, err := br.ImportBanners(ctx, lines, models.ImportOptions{Atomic: true})
+	if err != nil {
+		t.Fatalf("error importing atomically: %v", err)
+	}
+	if report.Succeeded != 1 || report.Failed != 2 || report.Committed != 0 {
+		t.Errorf("expected 1 succeeded, 2 failed and none committed, got %+v", report)
+	}
+
+	report, err = br.ImportBanners(ctx, lines, models.ImportOptions{ChunkSize: 2})
+	if err != nil {
+		t.Fatalf("error importing in chunks: %v", err)
+	}
+	if report.Committed != 1 || len(report.Errors) != 2 {
+		t.Errorf("expected 1 committed and 2 errors, got %+v", report)
+	}
+
+	content, err := br.ReadBanner(ctx, 1, 1)
+	expectContent(t, `{"v":2}`, content, err)
+	var exported []models.BannerRecord
+	err = br.ExportBanners(ctx, true, func(r models.BannerRecord) error {
+		exported = append(exported, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error exporting: %v", err)
+	}
+	if len(exported) != 1 || exported[0].Version != 2 || len(exported[0].Versions) != 1 {
+		t.Errorf("expected the imported banner with its old version, got %+v", exported)
+	}
+}
+
+func Test_authRepositoryConformance(t *testing.T) {
+	for _, backend := range storageBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			_, ar := backend.open(t)
+			ctx := context.Background()
+
+			id, err := ar.CreateUser(ctx, &models.User{Login: "alice", Password: "secret", TagID: 1})
+			if err != nil {
+				t.Fatalf("error creating user: %v", err)
+			}
+			u, err := ar.ReadUserByLogin(ctx, "alice")
+			if err != nil {
+				t.Fatalf("error reading user: %v", err)
+			}
+			if u.UserID != id || u.Password != "secret" || u.TagID != 1 || u.IsAdmin {
+				t.Errorf("unexpected user %+v", u)
+			}
+
+			if _, err = ar.CreateUser(ctx, &models.User{Login: "alice", Password: "other", TagID: 1}); err == nil {
+				t.Error("expected an error for a taken login")
+			}
+			if _, err = ar.CreateUser(ctx, &models.User{Login: "bob", Password: "secret", TagID: 99}); err == nil {
+				t.Error("expected an error for an unknown tag")
+			}
+			if _, err = ar.ReadUserByLogin(ctx, "bob"); !errors.Is(err, authRepository.ErrUserNotFound) {
+				t.Errorf("expected ErrUserNotFound, got %v", err)
+			}
+
+			external, err := ar.UpsertExternalUser(ctx, &models.User{Login: "oidc:carol", TagID: 2})
+			if err != nil {
+				t.Fatalf("error creating external user: %v", err)
+			}
+			again, err := ar.UpsertExternalUser(ctx, &models.User{Login: "oidc:carol", IsAdmin: true})
+			if err != nil || again != external {
+				t.Fatalf("expected the external user %d to be updated, got %d, %v", external, again, err)
+			}
+			if u, err = ar.ReadUserByLogin(ctx, "oidc:carol"); err != nil || !u.IsAdmin || u.TagID != 0 {
+				t.Errorf("expected an admin without a tag, got %+v, %v", u, err)
+			}
+
+			if _, err = ar.UpsertExternalUser(ctx, &models.User{Login: "alice"}); !errors.Is(err,
+				authRepository.ErrLoginTaken) {
+				t.Errorf("expected ErrLoginTaken, got %v", err)
+			}
+
+			if err = ar.CreateSignInFailure(ctx, "alice", "127.0.0.1"); err != nil {
+				t.Errorf("error creating sign in failure: %v", err)
+			}
+		})
+	}
+}
+
+func conformSearchEscaped(t *testing.T, br banner.BannerRepository) {
+	createConformBanner(t, br, []int{1}, 1, `{"title":"<img src=x onerror=alert(1)> sale & more","text":"no match"}`)
+
+	found, err := br.SearchBanners(context.Background(), models.BannerSearch{Query: "sale", Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("expected 1 banner, got %+v", found)
+	}
+	// The backends may cut the fragment differently, <mark> must be the only markup left in either.
+	title := found[0].Highlights["title"]
+	if !strings.Contains(title, "<mark>sale</mark>") {
+		t.Errorf("expected sale to be marked, got: %q", title)
+	}
+	if rest := strings.NewReplacer("<mark>", "", "</mark>", "").Replace(title); strings.ContainsAny(rest, "<>") {
+		t.Errorf("expected the content to be escaped, got: %q", title)
+	}
+}
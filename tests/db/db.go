@@ -5,9 +5,13 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"banner-service/internal/models"
+	bannerRepository "banner-service/internal/pkg/banner/repository"
+	"banner-service/internal/pkg/migrate"
+	"banner-service/migrations"
 )
 
 const (
@@ -18,34 +22,6 @@ const (
 	databasePort = 5432
 )
 
-const schema = `
-CREATE TABLE IF NOT EXISTS banner(
-    banner_id  SERIAL PRIMARY KEY,
-    content    BYTEA NOT NULL,
-    is_active  BOOLEAN DEFAULT FALSE,
-    created_at TIMESTAMP DEFAULT NOW(),
-    updated_at TIMESTAMP DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS tag(
-    tag_id  INT PRIMARY KEY
-);
-
-CREATE TABLE IF NOT EXISTS feature(
-    feature_id  INT PRIMARY KEY
-);
-
-CREATE TABLE IF NOT EXISTS banner_tag_feature(
-    banner_id INT,
-    tag_id INT,
-    feature_id INT,
-    FOREIGN KEY (banner_id) REFERENCES banner(banner_id) ON DELETE CASCADE,
-    FOREIGN KEY (tag_id) REFERENCES tag(tag_id) ON DELETE CASCADE,
-    FOREIGN KEY (feature_id) REFERENCES feature(feature_id) ON DELETE CASCADE,
-    CONSTRAINT PK_TagFeature PRIMARY KEY (tag_id, feature_id)
-);
-`
-
 type Config struct {
 	User string
 	Pass string
@@ -55,13 +31,22 @@ type Config struct {
 }
 
 func NewConnection(cfg Config) (*pgxpool.Pool, error) {
-	db, err := pgxpool.New(context.Background(), fmt.Sprintf("postgres://%v:%v@%v:%v/%v?sslmode=disable",
+	return newConnection(cfg, nil)
+}
+
+func newConnection(cfg Config, tracer pgx.QueryTracer) (*pgxpool.Pool, error) {
+	poolCfg, err := pgxpool.ParseConfig(fmt.Sprintf("postgres://%v:%v@%v:%v/%v?sslmode=disable",
 		cfg.User,
 		cfg.Pass,
 		cfg.Host,
 		cfg.Port,
 		cfg.Name))
+	if err != nil {
+		return nil, err
+	}
+	poolCfg.ConnConfig.Tracer = tracer
 
+	db, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
 	if err != nil {
 		err = errors.New("error happened in sql.Open: " + err.Error())
 		return nil, err
@@ -71,11 +56,19 @@ func NewConnection(cfg Config) (*pgxpool.Pool, error) {
 		return nil, err
 	}
 
-	if _, err = db.Exec(context.Background(), schema); err != nil {
-		return nil, errors.New("apply database schema")
+	m, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		return nil, err
 	}
+	if _, err = m.Up(context.Background()); err != nil {
+		return nil, fmt.Errorf("apply database migrations: %w", err)
+	}
+	db.Close()
 
-	return db, nil
+	// The statements are prepared once the tables exist.
+	prepared := poolCfg.Copy()
+	prepared.AfterConnect = bannerRepository.Prepare
+	return pgxpool.NewWithConfig(context.Background(), prepared)
 }
 
 // Open returns a new database connection for the test database.
@@ -89,8 +82,19 @@ func Open() (*pgxpool.Pool, error) {
 	})
 }
 
+// OpenTraced is Open with every query of the pool passed to the tracer.
+func OpenTraced(tracer pgx.QueryTracer) (*pgxpool.Pool, error) {
+	return newConnection(Config{
+		User: databaseUser,
+		Pass: databasePass,
+		Name: databaseName,
+		Host: databaseHost,
+		Port: databasePort,
+	}, tracer)
+}
+
 func Truncate(dbc *pgxpool.Pool) error {
-	stmt := `TRUNCATE TABLE banner_tag_feature, banner, tag, feature;`
+	stmt := `TRUNCATE TABLE banner_tag_feature, banner_version, banner, "user", sign_in_failure, tag, feature;`
 
 	if _, err := dbc.Exec(context.Background(), stmt); err != nil {
 		return errors.New("truncate test database tables")
@@ -165,10 +169,12 @@ func SeedBanners(dbc *pgxpool.Pool) ([]models.Banner, error) {
 		},
 	}
 
+	// The content is read back as jsonb formats it.
 	for i := range banners {
 		err := dbc.QueryRow(context.Background(),
-			`INSERT INTO banner(content, is_active) VALUES ($1, $2) RETURNING banner_id, created_at, updated_at;`,
-			banners[i].Content, banners[i].IsActive).Scan(&banners[i].BannerID, &banners[i].CreatedAt, &banners[i].UpdatedAt)
+			`INSERT INTO banner(content, is_active) VALUES ($1, $2) RETURNING banner_id, content, created_at, updated_at;`,
+			banners[i].Content, banners[i].IsActive).Scan(&banners[i].BannerID, &banners[i].Content,
+			&banners[i].CreatedAt, &banners[i].UpdatedAt)
 		if err != nil {
 			return nil, errors.New("prepare list insertion")
 		}
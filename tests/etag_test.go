@@ -0,0 +1,127 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/banner"
+	bannerHandler "banner-service/internal/pkg/banner/http"
+	bannerRepository "banner-service/internal/pkg/banner/repository"
+	bannerService "banner-service/internal/pkg/banner/service"
+	"banner-service/internal/pkg/memstore"
+)
+
+type versionsRepository struct {
+	banner.BannerRepository
+	err error
+}
+
+func (r *versionsRepository) ReadCurrentBannerByID(context.Context, int) (models.BannerVersion, error) {
+	return models.BannerVersion{}, r.err
+}
+
+func Test_bannerETag(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	store.AddTags(1)
+	store.AddFeatures(1)
+	repo := bannerRepository.NewMemoryBannerRepository(store)
+	id, err := repo.CreateBanner(ctx, &models.BannerPayload{
+		TagIDs: []int{1}, FeatureID: 1, Content: []byte(`{"v":1}`), IsActive: activeBanner,
+	})
+	if err != nil {
+		t.Fatalf("error creating banner: %v", err)
+	}
+
+	bh := bannerHandler.NewBannerHandler(bannerService.NewBannerService(repo, nil), logrus.New())
+	bh.SetCacheTTL(5 * time.Minute)
+
+	get := func(query, etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/user_banner?"+query, nil)
+		r = r.WithContext(context.WithValue(context.WithValue(r.Context(), "is_admin", false), "tag_id", 1))
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		bh.GetBanner(w, r)
+		return w
+	}
+
+	w := get("tag_id=1&feature_id=1", "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || etag[0] != '"' {
+		t.Fatalf("expected content with a strong etag, got %v, %q", w.Code, etag)
+	}
+	if e, a := "private, max-age=300", w.Header().Get("Cache-Control"); e != a {
+		t.Errorf("expected cache control: %q, got: %q", e, a)
+	}
+
+	for _, match := range []string{etag, `"other", ` + etag, "W/" + etag, "*"} {
+		w = get("tag_id=1&feature_id=1", match)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("ETag") != etag {
+			t.Errorf("expected 304 with the etag for If-None-Match %s, got %v: %s", match, w.Code, w.Body.String())
+		}
+	}
+
+	w = get("tag_id=1&feature_id=1&use_last_revision=true", etag)
+	if w.Code != http.StatusNotModified || w.Header().Get("Cache-Control") != "private, no-cache" {
+		t.Errorf("expected the latest revision to revalidate, got %v, %q", w.Code, w.Header().Get("Cache-Control"))
+	}
+
+	if err = repo.UpdateBanner(ctx, id, &models.BannerPayload{Content: []byte(`{"v":2}`)}); err != nil {
+		t.Fatalf("error updating banner: %v", err)
+	}
+	w = get("tag_id=1&feature_id=1", etag)
+	if w.Code != http.StatusOK || w.Body.String() != `{"v":2}` || w.Header().Get("ETag") == etag {
+		t.Errorf("expected changed content with a new etag, got %v: %s", w.Code, w.Body.String())
+	}
+
+	versions := func(etag string) *httptest.ResponseRecorder {
+		r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/banner/1", nil), map[string]string{"id": "1"})
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		bh.GetBannerVersions(w, r)
+		return w
+	}
+
+	w = versions("")
+	etag = w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected versions with an etag, got %v: %s", w.Code, w.Body.String())
+	}
+	if e, a := "private, no-cache", w.Header().Get("Cache-Control"); e != a {
+		t.Errorf("expected cache control of versions: %q, got: %q", e, a)
+	}
+	if w = versions(etag); w.Code != http.StatusNotModified {
+		t.Errorf("expected status code: %v, got status code: %v", http.StatusNotModified, w.Code)
+	}
+	if err = repo.UpdateBanner(ctx, id, &models.BannerPayload{Content: []byte(`{"v":3}`)}); err != nil {
+		t.Fatalf("error updating banner: %v", err)
+	}
+	if w = versions(etag); w.Code != http.StatusOK {
+		t.Errorf("expected status code after a new version: %v, got status code: %v", http.StatusOK, w.Code)
+	}
+}
+
+func Test_bannerVersionsError(t *testing.T) {
+	repo := &versionsRepository{err: errors.New("connection reset")}
+	bh := bannerHandler.NewBannerHandler(bannerService.NewBannerService(repo, nil), logrus.New())
+
+	r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/banner/1", nil), map[string]string{"id": "1"})
+	w := httptest.NewRecorder()
+	bh.GetBannerVersions(w, r)
+	if w.Code != http.StatusInternalServerError || w.Header().Get("ETag") != "" {
+		t.Errorf("expected status code: %v without an etag, got status code: %v, %q",
+			http.StatusInternalServerError, w.Code, w.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,96 @@
+package tests_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/models"
+	bannerRepository "banner-service/internal/pkg/banner/repository"
+	"banner-service/internal/pkg/filestore"
+	"banner-service/internal/pkg/memstore"
+)
+
+func openFileStore(t *testing.T, dir string) (*memstore.Store, *filestore.Files) {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	store := memstore.New()
+	files, err := filestore.Open(dir, store, 2, logger)
+	if err != nil {
+		t.Fatalf("error opening file store: %v", err)
+	}
+	store.AddTags(1, 2, 3)
+	store.AddFeatures(1, 2, 3)
+	return store, files
+}
+
+func Test_fileStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, files := openFileStore(t, dir)
+	br := bannerRepository.NewMemoryBannerRepository(store)
+	id, err := br.CreateBanner(ctx, &models.BannerPayload{
+		TagIDs: []int{1}, FeatureID: 1, Content: []byte(`{"v":1}`), IsActive: activeBanner,
+	})
+	if err != nil {
+		t.Fatalf("error creating banner: %v", err)
+	}
+	if err = br.UpdateBanner(ctx, id, &models.BannerPayload{Content: []byte(`{"v":2}`)}); err != nil {
+		t.Fatalf("error updating banner: %v", err)
+	}
+
+	// The files are left as a crash would leave them, with a line the crash cut short.
+	journal, err := os.OpenFile(filepath.Join(dir, "journal.jsonl"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = journal.WriteString(`{"seq":3,"change":{"ban`); err != nil {
+		t.Fatal(err)
+	}
+	journal.Close()
+
+	store, files = openFileStore(t, dir)
+	br = bannerRepository.NewMemoryBannerRepository(store)
+	current, err := br.ReadCurrentBannerByID(ctx, id)
+	expectContent(t, `{"v":2}`, current.Content, err)
+	if current.Version != 2 {
+		t.Errorf("expected version 2 after replay, got %d", current.Version)
+	}
+
+	next, err := br.CreateBanner(ctx, &models.BannerPayload{
+		TagIDs: []int{2}, FeatureID: 1, Content: []byte(`{}`), IsActive: activeBanner,
+	})
+	if err != nil {
+		t.Fatalf("error creating banner after replay: %v", err)
+	}
+	if next == id {
+		t.Errorf("expected a new id after replay, got %d again", next)
+	}
+	if err = br.DeleteBanner(ctx, id); err != nil {
+		t.Fatalf("error deleting banner: %v", err)
+	}
+	if err = files.Close(); err != nil {
+		t.Fatalf("error closing file store: %v", err)
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, "journal.jsonl")); err != nil || info.Size() != 0 {
+		t.Errorf("expected an empty journal after close, got %v, %v", info, err)
+	}
+
+	store, files = openFileStore(t, dir)
+	defer files.Close()
+	br = bannerRepository.NewMemoryBannerRepository(store)
+	if _, err = br.ReadBanner(ctx, 1, 1); err != bannerRepository.ErrBannerNotFound {
+		t.Errorf("expected the deleted banner to stay deleted, got %v", err)
+	}
+	content, err := br.ReadBanner(ctx, 2, 1)
+	expectContent(t, `{}`, content, err)
+}
@@ -0,0 +1,141 @@
+package tests_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"banner-service/internal/models"
+	bannerRepository "banner-service/internal/pkg/banner/repository"
+	"banner-service/tests/db"
+)
+
+type queryCounter struct {
+	n atomic.Int64
+}
+
+func (c *queryCounter) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	c.n.Add(1)
+	return ctx
+}
+
+func (c *queryCounter) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
+
+// TraceBatchStart counts a batch once, it is sent in one round trip.
+func (c *queryCounter) TraceBatchStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceBatchStartData) context.Context {
+	c.n.Add(1)
+	return ctx
+}
+
+func (c *queryCounter) TraceBatchQuery(context.Context, *pgx.Conn, pgx.TraceBatchQueryData) {}
+
+func (c *queryCounter) TraceBatchEnd(context.Context, *pgx.Conn, pgx.TraceBatchEndData) {}
+
+// readFilterBannersPerID is how ReadFilterBanners used to work: the ids first, then three queries a banner.
+func readFilterBannersPerID(ctx context.Context, pool *pgxpool.Pool, limit int) ([]models.Banner, error) {
+	rows, err := pool.Query(ctx, `SELECT banner_id FROM banner_tag_feature LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, err
+	}
+
+	banners := make([]models.Banner, 0, len(ids))
+	for _, id := range ids {
+		b := models.Banner{BannerID: id}
+		err = pool.QueryRow(ctx, `SELECT content, is_active, created_at, updated_at FROM banner WHERE banner_id=$1`,
+			id).Scan(&b.Content, &b.IsActive, &b.CreatedAt, &b.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		err = pool.QueryRow(ctx, `SELECT feature_id FROM banner_tag_feature WHERE banner_id=$1 LIMIT 1`, id).
+			Scan(&b.FeatureID)
+		if err != nil {
+			return nil, err
+		}
+		rows, err = pool.Query(ctx, `SELECT tag_id FROM banner_tag_feature WHERE banner_id=$1`, id)
+		if err != nil {
+			return nil, err
+		}
+		if b.TagIDs, err = pgx.CollectRows(rows, pgx.RowTo[int]); err != nil {
+			return nil, err
+		}
+		banners = append(banners, b)
+	}
+
+	return banners, nil
+}
+
+func BenchmarkReadFilterBanners(b *testing.B) {
+	counter := &queryCounter{}
+	testDB, err := db.OpenTraced(counter)
+	if err != nil {
+		b.Fatalf("error to connect: %v", err)
+	}
+	defer func() {
+		if err := db.Truncate(testDB); err != nil {
+			b.Errorf("error truncating test database tables: %v", err)
+		}
+		testDB.Close()
+	}()
+
+	tags, err := db.SeedTags(testDB)
+	if err != nil {
+		b.Fatalf("error seeding tags: %v", err)
+	}
+	features, err := db.SeedFeatures(testDB)
+	if err != nil {
+		b.Fatalf("error seeding features: %v", err)
+	}
+
+	// Every tag and feature pair is taken by one banner, which gives 100 banners.
+	payloads := make([]models.BannerPayload, 0, len(features))
+	for _, f := range features {
+		payloads = append(payloads, models.BannerPayload{
+			FeatureID: f,
+			Content:   []byte(`{"title":"some_title","text":"some_text","url":"some_url"}`),
+			IsActive:  models.NullBool{IsTrue: true, HasValue: true},
+		})
+	}
+	for _, t := range tags {
+		for i := range payloads {
+			payloads[i].TagIDs = []int{t}
+		}
+		br := bannerRepository.NewBannerRepository(testDB)
+		if _, err = br.CreateBanners(context.Background(), payloads, true); err != nil {
+			b.Fatalf("error seeding banners: %v", err)
+		}
+	}
+
+	const limit = 100
+	br := bannerRepository.NewBannerRepository(testDB)
+	run := func(read func() ([]models.Banner, error)) func(b *testing.B) {
+		return func(b *testing.B) {
+			counter.n.Store(0)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				banners, err := read()
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(banners) != limit {
+					b.Fatalf("expected %d banners, got %d", limit, len(banners))
+				}
+			}
+			b.ReportMetric(float64(counter.n.Load())/float64(b.N), "round_trips/op")
+		}
+	}
+
+	b.Run("aggregated", run(func() ([]models.Banner, error) {
+		page, err := br.ReadFilterBanners(context.Background(), models.BannerFilter{Limit: limit})
+		return page.Banners, err
+	}))
+	b.Run("per_id", run(func() ([]models.Banner, error) {
+		return readFilterBannersPerID(context.Background(), testDB, limit)
+	}))
+}
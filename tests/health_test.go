@@ -0,0 +1,80 @@
+package tests_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"banner-service/internal/pkg/health"
+)
+
+func ping(err error) func(context.Context) error {
+	return func(context.Context) error { return err }
+}
+
+func Test_readyz(t *testing.T) {
+	tests := []struct {
+		name           string
+		postgres       error
+		redis          error
+		shutdown       bool
+		expectedCode   int
+		expectedStatus string
+	}{
+		{name: "all up", expectedCode: http.StatusOK, expectedStatus: health.StatusOK},
+		{name: "redis down", redis: errors.New("connection refused"),
+			expectedCode: http.StatusOK, expectedStatus: health.StatusDegraded},
+		{name: "postgres down", postgres: errors.New("connection refused"),
+			expectedCode: http.StatusServiceUnavailable, expectedStatus: health.StatusUnavailable},
+		{name: "shutting down", shutdown: true,
+			expectedCode: http.StatusServiceUnavailable, expectedStatus: health.StatusShutdown},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := health.NewChecker(time.Second,
+				health.Check{Name: "postgres", Ping: ping(tc.postgres)},
+				health.Check{Name: "redis", Ping: ping(tc.redis), Optional: true},
+			)
+			if tc.shutdown {
+				c.Shutdown()
+			}
+
+			w := httptest.NewRecorder()
+			c.Ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if e, a := tc.expectedCode, w.Code; e != a {
+				t.Errorf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			var report health.Report
+			if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatal(err)
+			}
+			if e, a := tc.expectedStatus, report.Status; e != a {
+				t.Errorf("expected status: %v, got status: %v", e, a)
+			}
+			if len(report.Checks) != 2 {
+				t.Errorf("expected 2 checks, got %v", len(report.Checks))
+			}
+		})
+	}
+}
+
+func Test_readyzTimeout(t *testing.T) {
+	c := health.NewChecker(10*time.Millisecond, health.Check{Name: "postgres", Ping: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	w := httptest.NewRecorder()
+	c.Ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if e, a := http.StatusServiceUnavailable, w.Code; e != a {
+		t.Errorf("expected status code: %v, got status code: %v", e, a)
+	}
+}
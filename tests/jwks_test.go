@@ -0,0 +1,184 @@
+package tests_test
+
+import (
+	"banner-service/internal/models"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	authHandler "banner-service/internal/pkg/auth/http"
+	"banner-service/internal/utils/jwter"
+)
+
+func writeKey(t *testing.T, dir, name string, key interface{}) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("error marshalling key: %v", err)
+	}
+
+	path := filepath.Join(dir, name+".pem")
+	if err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("error writing key: %v", err)
+	}
+
+	return path
+}
+
+func Test_jwtKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating ed25519 key: %v", err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating rsa key: %v", err)
+	}
+
+	now := time.Now()
+	specs := []struct {
+		ID         string
+		Key        interface{}
+		ActiveFrom time.Time
+		ExpiresAt  time.Time
+	}{
+		{ID: "retired", Key: edKey, ActiveFrom: now.Add(-48 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+		{ID: "previous", Key: rsaKey, ActiveFrom: now.Add(-24 * time.Hour)},
+		{ID: "current", Key: edKey, ActiveFrom: now.Add(-time.Hour)},
+		{ID: "next", Key: rsaKey, ActiveFrom: now.Add(24 * time.Hour)},
+	}
+
+	keys := make(map[string]jwter.Key)
+	all := make([]jwter.Key, 0, len(specs))
+	for _, s := range specs {
+		k, err := jwter.LoadKey(s.ID, writeKey(t, dir, s.ID, s.Key), s.ActiveFrom, s.ExpiresAt)
+		if err != nil {
+			t.Fatalf("error loading key %s: %v", s.ID, err)
+		}
+		keys[s.ID] = k
+		all = append(all, k)
+	}
+
+	m, err := jwter.NewWithKeys(all, time.Hour)
+	if err != nil {
+		t.Fatalf("error creating manager: %v", err)
+	}
+
+	user := &models.User{UserID: 7, TagID: 3}
+
+	t.Run("Signs with current key", func(t *testing.T) {
+		token, err := m.GenerateJWT(user)
+		if err != nil {
+			t.Fatalf("error generating token: %v", err)
+		}
+
+		jws, err := jose.ParseSigned(token, []jose.SignatureAlgorithm{jose.EdDSA, jose.RS256})
+		if err != nil {
+			t.Fatalf("error parsing token: %v", err)
+		}
+
+		if e, a := "current", jws.Signatures[0].Header.KeyID; e != a {
+			t.Errorf("expected kid: %v, got kid: %v", e, a)
+		}
+
+		claims, err := m.ParseJWT(token)
+		if err != nil {
+			t.Fatalf("error verifying token: %v", err)
+		}
+
+		if e, a := user.UserID, claims.UserID; e != a {
+			t.Errorf("expected user_id: %v, got user_id: %v", e, a)
+		}
+	})
+
+	t.Run("Verifies tokens of previous key", func(t *testing.T) {
+		old, err := jwter.NewWithKeys([]jwter.Key{keys["previous"]}, time.Hour)
+		if err != nil {
+			t.Fatalf("error creating manager: %v", err)
+		}
+
+		token, err := old.GenerateJWT(user)
+		if err != nil {
+			t.Fatalf("error generating token: %v", err)
+		}
+
+		if _, err = m.ParseJWT(token); err != nil {
+			t.Errorf("expected token of previous key to verify: %v", err)
+		}
+	})
+
+	t.Run("Rejects retired and foreign keys", func(t *testing.T) {
+		retired, err := jwter.NewWithKeys([]jwter.Key{{ID: "retired", Private: edKey}}, time.Hour)
+		if err != nil {
+			t.Fatalf("error creating manager: %v", err)
+		}
+
+		token, err := retired.GenerateJWT(user)
+		if err != nil {
+			t.Fatalf("error generating token: %v", err)
+		}
+
+		if _, err = m.ParseJWT(token); err == nil {
+			t.Errorf("expected token of retired key to be rejected")
+		}
+
+		hsToken, err := jwter.New("secret", time.Hour).GenerateJWT(user)
+		if err != nil {
+			t.Fatalf("error generating token: %v", err)
+		}
+
+		if _, err = m.ParseJWT(hsToken); err == nil {
+			t.Errorf("expected HS256 token to be rejected")
+		}
+	})
+
+	t.Run("JWKS publishes unexpired keys", func(t *testing.T) {
+		ah := authHandler.NewAuthHandler(nil, logrus.New(), m)
+		w := httptest.NewRecorder()
+		ah.JWKS(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+		if e, a := http.StatusOK, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+
+		var set jose.JSONWebKeySet
+		if err := json.NewDecoder(w.Body).Decode(&set); err != nil {
+			t.Fatalf("error decoding jwks: %v", err)
+		}
+
+		ids := make([]string, 0, len(set.Keys))
+		for _, k := range set.Keys {
+			if !k.IsPublic() {
+				t.Errorf("key %s is not public", k.KeyID)
+			}
+			ids = append(ids, k.KeyID+":"+k.Algorithm)
+		}
+		sort.Strings(ids)
+
+		expected := []string{"current:EdDSA", "next:RS256", "previous:RS256"}
+		if d := cmp.Diff(expected, ids); d != "" {
+			t.Errorf("unexpected difference in jwks:\n%v", d)
+		}
+
+		if strings.Contains(w.Body.String(), `"d"`) {
+			t.Errorf("jwks leaks private key material")
+		}
+	})
+}
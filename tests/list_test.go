@@ -0,0 +1,274 @@
+package tests_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/banner"
+	bannerHandler "banner-service/internal/pkg/banner/http"
+	bannerRepository "banner-service/internal/pkg/banner/repository"
+	bannerService "banner-service/internal/pkg/banner/service"
+	"banner-service/tests/db"
+)
+
+type listRepository struct {
+	banner.BannerRepository
+	filter models.BannerFilter
+	page   models.BannerPage
+}
+
+func (r *listRepository) ReadFilterBanners(_ context.Context, f models.BannerFilter) (models.BannerPage, error) {
+	r.filter = f
+	return r.page, nil
+}
+
+func Test_bannerListFilter(t *testing.T) {
+	updated := time.Date(2024, 4, 1, 12, 30, 0, 123456000, time.UTC)
+	repo := &listRepository{page: models.BannerPage{
+		Banners: []models.Banner{{BannerID: 7, TagIDs: []int{1}, FeatureID: 1, Content: json.RawMessage(`{}`)}},
+		Total:   42,
+		Next:    &models.BannerCursor{SortBy: "updated_at", Desc: true, ID: 7, Value: updated},
+	}}
+	bh := bannerHandler.NewBannerHandler(bannerService.NewBannerService(repo, nil), logrus.New())
+
+	list := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		bh.GetBannerList(w, httptest.NewRequest(http.MethodGet, "/api/banner?"+query, nil))
+		return w
+	}
+
+	w := list("feature_id=3&is_active=false&updated_from=2024-04-01T00:00:00%2B03:00&sort=updated_at&order=desc&limit=1")
+	if e, a := http.StatusOK, w.Code; e != a {
+		t.Fatalf("expected status code: %v, got status code: %v: %s", e, a, w.Body.String())
+	}
+	f := repo.filter
+	if f.FeatureID != 3 || f.IsActive == nil || *f.IsActive || f.SortBy != "updated_at" || !f.Desc || f.Limit != 1 {
+		t.Errorf("unexpected filter: %+v", f)
+	}
+	if e, a := time.Date(2024, 3, 31, 21, 0, 0, 0, time.UTC), f.UpdatedFrom; !e.Equal(a) {
+		t.Errorf("expected updated_from: %v, got: %v", e, a)
+	}
+	if e, a := "42", w.Header().Get("X-Total-Count"); e != a {
+		t.Errorf("expected total: %v, got: %v", e, a)
+	}
+
+	cursor := w.Header().Get("X-Next-Cursor")
+	if cursor == "" {
+		t.Fatal("expected a next cursor")
+	}
+	link, err := url.Parse(w.Header().Get("Link")[1:])
+	if err != nil || link.Query().Get("cursor") != cursor || link.Query().Get("feature_id") != "3" {
+		t.Errorf("expected link to the next page with the same filters, got %q", w.Header().Get("Link"))
+	}
+
+	// The cursor carries the sort, the next page need not repeat it.
+	if w = list("feature_id=3&limit=1&cursor=" + cursor); w.Code != http.StatusOK {
+		t.Fatalf("expected status code: %v, got status code: %v: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	after := repo.filter.After
+	if after == nil || after.ID != 7 || !after.Value.Equal(updated) || repo.filter.SortBy != "updated_at" ||
+		!repo.filter.Desc {
+		t.Errorf("unexpected filter for the next page: %+v, after %+v", repo.filter, after)
+	}
+
+	if w = list("content.title=Sale&content.meta.price=5&content_has=url"); w.Code != http.StatusOK {
+		t.Fatalf("expected status code: %v, got status code: %v: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	expected := []string{"url=<nil>", "meta.price=5", "title=Sale"}
+	if e, a := len(expected), len(repo.filter.Content); e != a {
+		t.Fatalf("expected %d content filters, got %+v", e, repo.filter.Content)
+	}
+	for i, c := range repo.filter.Content {
+		value := "<nil>"
+		if c.Value != nil {
+			value = *c.Value
+		}
+		if e, a := expected[i], strings.Join(c.Path, ".")+"="+value; e != a {
+			t.Errorf("expected content filter %s, got %s", e, a)
+		}
+	}
+
+	for _, query := range []string{
+		"content.=x",
+		"content.a..b=x",
+		"content.a'b=x",
+		"content_has=a.b.c.d.e.f",
+		"sort=name",
+		"order=up",
+		"is_active=sometimes",
+		"created_to=yesterday",
+		"limit=-1",
+		"cursor=not-a-cursor",
+		"offset=10&cursor=" + cursor,
+		"sort=created_at&cursor=" + cursor,
+	} {
+		if e, a := http.StatusBadRequest, list(query).Code; e != a {
+			t.Errorf("%s: expected status code: %v, got status code: %v", query, e, a)
+		}
+	}
+}
+
+func Test_bannerListPagination(t *testing.T) {
+	testDB, err := db.Open()
+	if err != nil {
+		t.Fatalf("error to connect: %v", err)
+	}
+	defer func() {
+		if err := db.Truncate(testDB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+		testDB.Close()
+	}()
+
+	if _, err = db.SeedFeatures(testDB); err != nil {
+		t.Fatalf("error seeding features: %v", err)
+	}
+	if _, err = db.SeedTags(testDB); err != nil {
+		t.Fatalf("error seeding tags: %v", err)
+	}
+	seeded, err := db.SeedBanners(testDB)
+	if err != nil {
+		t.Fatalf("error seeding banners: %v", err)
+	}
+
+	br := bannerRepository.NewBannerRepository(testDB)
+	bh := bannerHandler.NewBannerHandler(bannerService.NewBannerService(br, nil), logrus.New())
+
+	var ids []int
+	target := "/api/banner?limit=4&sort=created_at&order=desc"
+	for pages := 0; target != ""; pages++ {
+		if pages > len(seeded) {
+			t.Fatal("pagination does not end")
+		}
+
+		w := httptest.NewRecorder()
+		bh.GetBannerList(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if e, a := http.StatusOK, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v: %s", e, a, w.Body.String())
+		}
+		if e, a := strconv.Itoa(len(seeded)), w.Header().Get("X-Total-Count"); e != a {
+			t.Errorf("expected total: %v, got: %v", e, a)
+		}
+
+		var page []models.Banner
+		if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+			t.Fatalf("error decoding response body: %v", err)
+		}
+		for _, b := range page {
+			ids = append(ids, b.BannerID)
+		}
+
+		target = ""
+		if next := w.Header().Get("X-Next-Cursor"); next != "" {
+			target = "/api/banner?limit=4&cursor=" + next
+		}
+	}
+
+	if e, a := len(seeded), len(ids); e != a {
+		t.Fatalf("expected %d banners over all pages, got %d: %v", e, a, ids)
+	}
+	// The seeded banners are created one after another, the newest first.
+	for i, b := range seeded {
+		if e, a := b.BannerID, ids[len(ids)-1-i]; e != a {
+			t.Errorf("expected banner %d at position %d, got %d", e, len(ids)-1-i, a)
+		}
+	}
+}
+
+func Test_bannerListContentFilter(t *testing.T) {
+	testDB, err := db.Open()
+	if err != nil {
+		t.Fatalf("error to connect: %v", err)
+	}
+	defer func() {
+		if err := db.Truncate(testDB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+		testDB.Close()
+	}()
+
+	if _, err = db.SeedFeatures(testDB); err != nil {
+		t.Fatalf("error seeding features: %v", err)
+	}
+	if _, err = db.SeedTags(testDB); err != nil {
+		t.Fatalf("error seeding tags: %v", err)
+	}
+
+	br := bannerRepository.NewBannerRepository(testDB)
+	active := models.NullBool{IsTrue: true, HasValue: true}
+	results, err := br.CreateBanners(context.Background(), []models.BannerPayload{
+		{TagIDs: []int{1}, FeatureID: 1, IsActive: active,
+			Content: []byte(`{"title":"Sale","meta":{"price":5,"lang":"en"}}`)},
+		{TagIDs: []int{2}, FeatureID: 2, IsActive: active,
+			Content: []byte(`{"title":"Sale","meta":{"price":"5"},"url":"https://b.example"}`)},
+		{TagIDs: []int{3}, FeatureID: 3, IsActive: active, Content: []byte(`{"title":"News"}`)},
+	}, true)
+	if err != nil {
+		t.Fatalf("error creating banners: %v", err)
+	}
+
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name     string
+		content  []models.ContentFilter
+		expected []int
+	}{
+		{
+			name:     "string value",
+			content:  []models.ContentFilter{{Path: []string{"title"}, Value: str("Sale")}},
+			expected: []int{results[0].BannerID, results[1].BannerID},
+		},
+		{
+			name:     "nested number or string",
+			content:  []models.ContentFilter{{Path: []string{"meta", "price"}, Value: str("5")}},
+			expected: []int{results[0].BannerID, results[1].BannerID},
+		},
+		{
+			name: "value and key",
+			content: []models.ContentFilter{
+				{Path: []string{"title"}, Value: str("Sale")},
+				{Path: []string{"url"}},
+			},
+			expected: []int{results[1].BannerID},
+		},
+		{
+			name:     "nested key",
+			content:  []models.ContentFilter{{Path: []string{"meta", "lang"}}},
+			expected: []int{results[0].BannerID},
+		},
+		{
+			name:    "no match",
+			content: []models.ContentFilter{{Path: []string{"title"}, Value: str("sale")}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := br.ReadFilterBanners(context.Background(), models.BannerFilter{Content: tc.content})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if e, a := len(tc.expected), page.Total; e != a {
+				t.Errorf("expected total %d, got %d", e, a)
+			}
+			ids := make([]int, 0, len(page.Banners))
+			for _, b := range page.Banners {
+				ids = append(ids, b.BannerID)
+			}
+			if e, a := fmt.Sprint(tc.expected), fmt.Sprint(ids); len(tc.expected) > 0 && e != a {
+				t.Errorf("expected banners %s, got %s", e, a)
+			}
+		})
+	}
+}
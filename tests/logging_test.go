@@ -0,0 +1,133 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"banner-service/internal/pkg/logging"
+	"banner-service/internal/pkg/middleware"
+)
+
+func newAccessLogRouter(t *testing.T) (*mux.Router, *bytes.Buffer) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	if err := logging.Configure(logger, "info", "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	r := mux.NewRouter()
+	r.Use(middleware.NewAccessLog(logger).Log)
+	r.HandleFunc("/api/user_banner", func(w http.ResponseWriter, r *http.Request) {
+		logging.SetUserID(r.Context(), 7)
+		w.WriteHeader(http.StatusNotFound)
+	}).Name("user_banner")
+
+	return r, &buf
+}
+
+func Test_accessLog(t *testing.T) {
+	r, buf := newAccessLogRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/user_banner", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if e, a := "abc-123", w.Header().Get("X-Request-ID"); e != a {
+		t.Errorf("expected request id: %v, got request id: %v", e, a)
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected json log line, got %q: %v", buf.String(), err)
+	}
+
+	expected := map[string]interface{}{
+		"request_id": "abc-123",
+		"route":      "user_banner",
+		"method":     http.MethodGet,
+		"status":     float64(http.StatusNotFound),
+		"user_id":    float64(7),
+		"level":      "warning",
+	}
+	for k, e := range expected {
+		if a := entry[k]; a != e {
+			t.Errorf("field %s: expected %v, got %v", k, e, a)
+		}
+	}
+	if _, ok := entry["duration_ms"]; !ok {
+		t.Errorf("expected duration_ms field")
+	}
+}
+
+func Test_accessLogGeneratesRequestID(t *testing.T) {
+	r, _ := newAccessLogRouter(t)
+
+	for _, id := range []string{"", "bad id\nwith newline"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/user_banner", nil)
+		req.Header.Set("X-Request-ID", id)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if a := w.Header().Get("X-Request-ID"); a == "" || a == id {
+			t.Errorf("expected generated request id for %q, got %q", id, a)
+		}
+	}
+}
+
+func Test_accessLogTrace(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	if err := logging.Configure(logger, "info", "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	r := mux.NewRouter()
+	r.Use(middleware.Trace, middleware.NewAccessLog(logger).Log)
+	r.HandleFunc("/api/user_banner", func(w http.ResponseWriter, r *http.Request) {
+		logging.Entry(r.Context(), logger).Info("handler")
+	}).Name("user_banner")
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/api/user_banner", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if e, a := 2, len(lines); e != a {
+		t.Fatalf("expected %d log lines, got %d: %s", e, a, buf.String())
+	}
+	var handler, access map[string]interface{}
+	if err := json.Unmarshal(lines[0], &handler); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(lines[1], &access); err != nil {
+		t.Fatal(err)
+	}
+	if access["trace_id"] != traceID || access["trace_id"] != handler["trace_id"] {
+		t.Errorf("expected trace id %v on the access line and the handler line, got %v and %v",
+			traceID, access["trace_id"], handler["trace_id"])
+	}
+	if access["span_id"] == nil || access["span_id"] != handler["span_id"] {
+		t.Errorf("expected the span of the request on both lines, got %v and %v", access["span_id"], handler["span_id"])
+	}
+}
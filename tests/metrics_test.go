@@ -0,0 +1,54 @@
+package tests_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"banner-service/internal/pkg/metrics"
+	"banner-service/internal/pkg/middleware"
+)
+
+func Test_metricsEndpoint(t *testing.T) {
+	m := metrics.New()
+
+	r := mux.NewRouter()
+	r.Use(middleware.NewMetrics(m).Measure)
+	r.Handle("/metrics", m.Handler()).Name("metrics")
+	r.HandleFunc("/api/user_banner", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Name("user_banner")
+
+	for i := 0; i < 2; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/user_banner", nil))
+	}
+	m.ObserveCache("get", "hit")
+	m.ObserveCache("get", "miss")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if e, a := http.StatusOK, w.Code; e != a {
+		t.Fatalf("expected status code: %v, got status code: %v", e, a)
+	}
+
+	body, _ := io.ReadAll(w.Body)
+
+	expected := []string{
+		`banner_service_http_requests_total{code="404",method="GET",route="user_banner"} 2`,
+		`banner_service_http_request_duration_seconds_count{code="404",method="GET",route="user_banner"} 2`,
+		`banner_service_cache_operations_total{operation="get",result="hit"} 1`,
+		`banner_service_cache_operations_total{operation="get",result="miss"} 1`,
+		`banner_service_active_banners 0`,
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(string(body), e) {
+			t.Errorf("expected metrics to contain %q", e)
+		}
+	}
+}
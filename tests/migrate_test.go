@@ -0,0 +1,104 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"testing/fstest"
+
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/app"
+	"banner-service/internal/pkg/config"
+	"banner-service/internal/pkg/migrate"
+	"banner-service/migrations"
+	"banner-service/tests/db"
+)
+
+func Test_loadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_second.up.sql":   {Data: []byte("CREATE TABLE b();")},
+		"0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"0001_first.up.sql":    {Data: []byte("CREATE TABLE a();")},
+		"README.md":            {Data: []byte("ignored")},
+	}
+
+	ms, err := migrate.Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := 2, len(ms); e != a {
+		t.Fatalf("expected %d migrations, got %d", e, a)
+	}
+	if ms[0].Version != 1 || ms[0].Name != "first" || ms[0].Down != "" {
+		t.Errorf("unexpected first migration: %+v", ms[0])
+	}
+	if ms[1].Version != 2 || ms[1].Down != "DROP TABLE b;" {
+		t.Errorf("unexpected second migration: %+v", ms[1])
+	}
+
+	fsys["0002_other.up.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	if _, err = migrate.Load(fsys); err == nil {
+		t.Errorf("expected error for duplicate version")
+	}
+}
+
+func Test_embeddedMigrations(t *testing.T) {
+	ms, err := migrate.Load(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ms) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+	for _, m := range ms {
+		if m.Down == "" {
+			t.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
+
+func Test_migrationStatus(t *testing.T) {
+	testDB, err := db.Open()
+	if err != nil {
+		t.Fatalf("error to connect: %v", err)
+	}
+	defer testDB.Close()
+
+	m, err := migrate.New(testDB, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			t.Errorf("expected migration %d_%s to be applied", s.Version, s.Name)
+		}
+	}
+}
+
+func Test_migrateDatabaseConfig(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	// Only the postgres section is set, no JWT secret, Redis or storage settings.
+	path := writeConfig(t, "postgres:\n  dbName: \"bannerDB\"\n  dbHost: \"127.0.0.1\"\n  dbPort: 1\n  dbUser: \"root\"\n")
+	var invalid *config.ValidationError
+	err := app.NewApp(logger, path).Migrate([]string{"status"}, io.Discard)
+	if err == nil || errors.As(err, &invalid) {
+		t.Errorf("expected the connection to fail after the config is accepted, got: %v", err)
+	}
+
+	path = writeConfig(t, "postgres:\n  dbName: \"bannerDB\"\n")
+	err = app.NewApp(logger, path).Migrate([]string{"status"}, io.Discard)
+	if !errors.As(err, &invalid) || len(invalid.Problems) != 2 {
+		t.Errorf("expected dbHost and dbUser to be reported, got: %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+const keyID = "stub-key"
+
+type grant struct {
+	challenge string
+	nonce     string
+	subject   string
+	groups    []string
+}
+
+// Issuer is a stub OpenID provider serving discovery, JWKS and token endpoints.
+type Issuer struct {
+	Server   *httptest.Server
+	ClientID string
+
+	key    *rsa.PrivateKey
+	mu     sync.Mutex
+	grants map[string]grant
+}
+
+func NewIssuer(clientID string) (*Issuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	iss := &Issuer{ClientID: clientID, key: key, grants: make(map[string]grant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", iss.discovery)
+	mux.HandleFunc("/jwks", iss.jwks)
+	mux.HandleFunc("/token", iss.token)
+	iss.Server = httptest.NewServer(mux)
+
+	return iss, nil
+}
+
+func (iss *Issuer) Close() {
+	iss.Server.Close()
+}
+
+func (iss *Issuer) URL() string {
+	return iss.Server.URL
+}
+
+// Authorize plays the user consenting on the provider and returns the authorization code.
+func (iss *Issuer) Authorize(challenge, nonce, subject string, groups []string) string {
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+
+	code := base64.RawURLEncoding.EncodeToString([]byte(subject + nonce))
+	iss.grants[code] = grant{challenge: challenge, nonce: nonce, subject: subject, groups: groups}
+	return code
+}
+
+func (iss *Issuer) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"issuer":                                iss.URL(),
+		"authorization_endpoint":                iss.URL() + "/authorize",
+		"token_endpoint":                        iss.URL() + "/token",
+		"jwks_uri":                              iss.URL() + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (iss *Issuer) jwks(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &iss.key.PublicKey, KeyID: keyID, Algorithm: string(jose.RS256), Use: "sig"},
+	}})
+}
+
+func (iss *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	iss.mu.Lock()
+	g, ok := iss.grants[r.PostForm.Get("code")]
+	delete(iss.grants, r.PostForm.Get("code"))
+	iss.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: iss.key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", keyID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":    iss.URL(),
+		"sub":    g.subject,
+		"aud":    iss.ClientID,
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"iat":    time.Now().Unix(),
+		"nonce":  g.nonce,
+		"groups": g.groups,
+	})
+
+	jws, err := signer.Sign(claims)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	idToken, err := jws.CompactSerialize()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"access_token": "stub-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,146 @@
+package tests_test
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	authHandler "banner-service/internal/pkg/auth/http"
+	"banner-service/internal/pkg/auth/oidc"
+	authService "banner-service/internal/pkg/auth/sevice"
+	"banner-service/internal/utils/jwter"
+	stubOIDC "banner-service/tests/oidc"
+)
+
+func Test_oidcSignIn(t *testing.T) {
+	logger := logrus.New()
+
+	issuer, err := stubOIDC.NewIssuer("banner-service")
+	if err != nil {
+		t.Fatalf("error starting stub issuer: %v", err)
+	}
+	defer issuer.Close()
+
+	provider, err := oidc.NewProvider(context.Background(), oidc.Config{
+		Issuer:      issuer.URL(),
+		ClientID:    issuer.ClientID,
+		RedirectURL: "http://banner-service/api/oidc/callback",
+		AdminGroups: []string{"banner-admins"},
+		TagGroups:   map[string]int{"tag-3": 3, "tag-2": 2},
+	})
+	if err != nil {
+		t.Fatalf("error creating provider: %v", err)
+	}
+
+	tokenManager := jwter.New("secret", time.Hour)
+
+	tests := []struct {
+		Name            string
+		Groups          []string
+		TamperState     bool
+		ExpectedCode    int
+		ExpectedIsAdmin bool
+		ExpectedTagID   int
+	}{
+		{
+			Name:            "Admin group",
+			Groups:          []string{"banner-admins"},
+			ExpectedCode:    http.StatusOK,
+			ExpectedIsAdmin: true,
+		},
+		{
+			Name:          "Lowest tag group wins",
+			Groups:        []string{"tag-3", "tag-2", "other"},
+			ExpectedCode:  http.StatusOK,
+			ExpectedTagID: 2,
+		},
+		{
+			Name:         "No mapped groups",
+			Groups:       []string{"other"},
+			ExpectedCode: http.StatusForbidden,
+		},
+		{
+			Name:         "State mismatch",
+			Groups:       []string{"banner-admins"},
+			TamperState:  true,
+			ExpectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			repo := &stubAuthRepository{users: map[string]models.User{}}
+			oh := authHandler.NewOIDCHandler(authService.NewAuthService(repo, nil, logger), provider, logger, tokenManager)
+
+			w := httptest.NewRecorder()
+			oh.Login(w, httptest.NewRequest(http.MethodGet, "/api/oidc/login", nil))
+
+			if e, a := http.StatusFound, w.Code; e != a {
+				t.Fatalf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			redirect, err := url.Parse(w.Header().Get("Location"))
+			if err != nil {
+				t.Fatalf("error parsing redirect: %v", err)
+			}
+
+			q := redirect.Query()
+			if e, a := "S256", q.Get("code_challenge_method"); e != a {
+				t.Errorf("expected code challenge method: %v, got: %v", e, a)
+			}
+
+			code := issuer.Authorize(q.Get("code_challenge"), q.Get("nonce"), "user-1", test.Groups)
+			state := q.Get("state")
+			if test.TamperState {
+				state = "forged"
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/oidc/callback?"+url.Values{
+				"code":  {code},
+				"state": {state},
+			}.Encode(), nil)
+			for _, c := range w.Result().Cookies() {
+				req.AddCookie(c)
+			}
+
+			w = httptest.NewRecorder()
+			oh.Callback(w, req)
+
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Fatalf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			if test.ExpectedCode != http.StatusOK {
+				return
+			}
+
+			var token string
+			for _, c := range w.Result().Cookies() {
+				if c.Name == "AccessToken" {
+					token = c.Value
+				}
+			}
+
+			claims, err := tokenManager.ParseJWT(token)
+			if err != nil {
+				t.Fatalf("error parsing issued token: %v", err)
+			}
+
+			if e, a := test.ExpectedIsAdmin, claims.IsAdmin; e != a {
+				t.Errorf("expected is_admin: %v, got is_admin: %v", e, a)
+			}
+
+			if e, a := test.ExpectedTagID, claims.TagID; e != a {
+				t.Errorf("expected tag_id: %v, got tag_id: %v", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
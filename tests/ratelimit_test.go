@@ -0,0 +1,198 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/pkg/middleware"
+	"banner-service/internal/pkg/ratelimit"
+)
+
+func Test_rateLimitMiddleware(t *testing.T) {
+	logger := logrus.New()
+
+	tests := []struct {
+		Name              string
+		Route             string
+		UserIDs           []int
+		ExpectedCodes     []int
+		ExpectedRemaining []string
+	}{
+		{
+			Name:              "Route limit exceeded",
+			Route:             "user_banner",
+			UserIDs:           []int{1, 1, 1},
+			ExpectedCodes:     []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+			ExpectedRemaining: []string{"1", "0", "0"},
+		},
+		{
+			Name:              "Limits are per user",
+			Route:             "user_banner",
+			UserIDs:           []int{1, 1, 2},
+			ExpectedCodes:     []int{http.StatusOK, http.StatusOK, http.StatusOK},
+			ExpectedRemaining: []string{"1", "0", "1"},
+		},
+		{
+			Name:              "Default limit",
+			Route:             "banner_list",
+			UserIDs:           []int{1, 1},
+			ExpectedCodes:     []int{http.StatusOK, http.StatusTooManyRequests},
+			ExpectedRemaining: []string{"0", "0"},
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			limiter := ratelimit.NewTokenBucket(ratelimit.NewMemoryStore(), ratelimit.Limit{Rate: 0.001, Burst: 1},
+				map[string]ratelimit.Limit{"user_banner": {Rate: 0.001, Burst: 2}})
+			rl := middleware.NewRateLimit(logger, limiter)
+
+			r := mux.NewRouter()
+			r.Handle("/banner", rl.Limit(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))).Name(test.Route)
+
+			for i, userID := range test.UserIDs {
+				req := httptest.NewRequest(http.MethodGet, "/banner", nil)
+				req = req.WithContext(context.WithValue(req.Context(), "user_id", userID))
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+
+				if e, a := test.ExpectedCodes[i], w.Code; e != a {
+					t.Errorf("request %d: expected status code: %v, got status code: %v", i, e, a)
+				}
+
+				if e, a := test.ExpectedRemaining[i], w.Header().Get("RateLimit-Remaining"); e != a {
+					t.Errorf("request %d: expected remaining: %v, got remaining: %v", i, e, a)
+				}
+
+				if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+					t.Errorf("request %d: expected Retry-After header", i)
+				}
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func Test_rateLimitUnauthenticated(t *testing.T) {
+	limiter := ratelimit.NewTokenBucket(ratelimit.NewMemoryStore(), ratelimit.Limit{Rate: 0.001, Burst: 1}, nil)
+	rl := middleware.NewRateLimit(logrus.New(), limiter)
+
+	r := mux.NewRouter()
+	r.Handle("/sign_in", rl.Limit(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))).Name("sign_in")
+
+	for i, key := range []string{"first", "second", "third"} {
+		req := httptest.NewRequest(http.MethodPost, "/sign_in", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		expected := http.StatusTooManyRequests
+		if i == 0 {
+			expected = http.StatusOK
+		}
+		if e, a := expected, w.Code; e != a {
+			t.Errorf("request %d with X-API-Key %s: expected status code: %v, got status code: %v", i, key, e, a)
+		}
+	}
+}
+
+func Test_memoryStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := ratelimit.NewMemoryStore()
+	now := time.Now()
+
+	if _, _, err := store.Take(ctx, "bucket", 1, 2, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Hit(ctx, "window", now, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Incr(ctx, "counter", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Lock(ctx, "lock", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if e, a := 4, store.Len(); e != a {
+		t.Fatalf("expected keys: %v, got keys: %v", e, a)
+	}
+
+	// The window has a hit within the last minute, the full bucket, the counter and the lock have expired.
+	if _, _, err := store.Hit(ctx, "window", now.Add(90*time.Second), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Take(ctx, "other", 1, 1, now.Add(2*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if e, a := 2, store.Len(); e != a {
+		t.Errorf("expected the window and the new bucket only, got keys: %v", a)
+	}
+
+	if _, _, err := store.Take(ctx, "other", 1, 1, now.Add(4*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if e, a := 1, store.Len(); e != a {
+		t.Errorf("expected the expired window to be dropped, got keys: %v", a)
+	}
+}
+
+type flakyStore struct {
+	*ratelimit.MemoryStore
+	err error
+}
+
+func (s *flakyStore) Take(ctx context.Context, key string, rate float64, burst int,
+	now time.Time) (bool, float64, error) {
+	if s.err != nil {
+		return false, 0, s.err
+	}
+	return s.MemoryStore.Take(ctx, key, rate, burst, now)
+}
+
+func Test_fallbackStoreLogging(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&out)
+
+	primary := &flakyStore{MemoryStore: ratelimit.NewMemoryStore(), err: errors.New("connection refused")}
+	store := ratelimit.NewFallbackStore(primary, ratelimit.NewMemoryStore(), logger)
+	take := func() {
+		if _, _, err := store.Take(context.Background(), "k", 100, 100, time.Now()); err != nil {
+			t.Fatalf("expected the fallback to answer, got %v", err)
+		}
+	}
+
+	for i := 0; i < 5; i++ {
+		take()
+	}
+	if e, a := 1, strings.Count(out.String(), "using fallback"); e != a {
+		t.Errorf("expected %d warning while the store is down, got %d", e, a)
+	}
+
+	primary.err = nil
+	take()
+	take()
+	if e, a := 1, strings.Count(out.String(), "rate limit store is back"); e != a {
+		t.Errorf("expected %d recovery message, got %d", e, a)
+	}
+
+	primary.err = errors.New("connection refused")
+	take()
+	if e, a := 2, strings.Count(out.String(), "using fallback"); e != a {
+		t.Errorf("expected a warning for the second outage, got %d warnings", a)
+	}
+}
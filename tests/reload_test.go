@@ -0,0 +1,114 @@
+package tests_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"banner-service/internal/pkg/config"
+	"banner-service/internal/pkg/middleware"
+)
+
+func Test_configDiff(t *testing.T) {
+	old := &config.Config{}
+	old.RedisTTL = time.Minute
+	old.DBPass = "old"
+	old.Address = "localhost:8080"
+
+	updated := *old
+	updated.RedisTTL = 2 * time.Minute
+	updated.DBPass = "new"
+
+	changes := config.Diff(old, &updated)
+	if e, a := 2, len(changes); e != a {
+		t.Fatalf("expected %d changes, got %d: %v", e, a, changes)
+	}
+
+	byField := make(map[string]config.Change, len(changes))
+	for _, c := range changes {
+		byField[c.Field] = c
+	}
+
+	if c, ok := byField["redis.cacheTTL"]; !ok || !c.Reloadable || c.String() != "redis.cacheTTL: 1m0s -> 2m0s" {
+		t.Errorf("unexpected cacheTTL change: %+v", c)
+	}
+	if c, ok := byField["postgres.dbPass"]; !ok || c.Reloadable || strings.Contains(c.String(), "new") {
+		t.Errorf("unexpected dbPass change: %v", c)
+	}
+}
+
+func Test_configWatch(t *testing.T) {
+	path := writeConfig(t, testConfig)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan struct{}, 1)
+	go config.Watch(ctx, path, 10*time.Millisecond, func() { changed <- struct{}{} })
+
+	time.Sleep(30 * time.Millisecond)
+	if err := os.WriteFile(path, []byte(testConfig+"\nlogging:\n  level: debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("expected change notification")
+	}
+}
+
+func Test_corsPreflight(t *testing.T) {
+	cors := middleware.NewCORS(middleware.CORSPolicy{
+		AllowedOrigins: []string{"https://admin.example.com"},
+		AllowedMethods: []string{"GET", "PATCH"},
+		AllowedHeaders: []string{"Authorization"},
+		MaxAge:         time.Minute,
+	})
+	h := cors.Handle(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/api/banner", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "PATCH")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := preflight("https://admin.example.com")
+	if e, a := http.StatusNoContent, w.Code; e != a {
+		t.Errorf("expected status code: %v, got status code: %v", e, a)
+	}
+	if e, a := "https://admin.example.com", w.Header().Get("Access-Control-Allow-Origin"); e != a {
+		t.Errorf("expected allowed origin: %v, got: %v", e, a)
+	}
+	if e, a := "60", w.Header().Get("Access-Control-Max-Age"); e != a {
+		t.Errorf("expected max age: %v, got: %v", e, a)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/banner", nil)
+	req.Header.Set("Origin", "https://admin.example.com")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	for _, header := range []string{"X-Total-Count", "X-Next-Cursor", "Link", "ETag"} {
+		if !strings.Contains(w.Header().Get("Access-Control-Expose-Headers"), header) {
+			t.Errorf("expected %s to be exposed, got: %v", header, w.Header().Get("Access-Control-Expose-Headers"))
+		}
+	}
+
+	cors.SetPolicy(middleware.CORSPolicy{})
+	w = preflight("https://admin.example.com")
+	if a := w.Header().Get("Access-Control-Allow-Origin"); a != "" {
+		t.Errorf("expected no allowed origin after policy change, got: %v", a)
+	}
+	if e, a := http.StatusTeapot, w.Code; e != a {
+		t.Errorf("expected request to pass through, got status code: %v", a)
+	}
+}
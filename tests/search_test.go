@@ -0,0 +1,149 @@
+package tests_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/banner"
+	bannerHandler "banner-service/internal/pkg/banner/http"
+	bannerRepository "banner-service/internal/pkg/banner/repository"
+	bannerService "banner-service/internal/pkg/banner/service"
+	"banner-service/tests/db"
+)
+
+type searchRepository struct {
+	banner.BannerRepository
+	search models.BannerSearch
+}
+
+func (r *searchRepository) SearchBanners(_ context.Context,
+	search models.BannerSearch) ([]models.BannerSearchResult, error) {
+	r.search = search
+	return nil, nil
+}
+
+func Test_searchBannersParams(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expected     models.BannerSearch
+	}{
+		{
+			name:         "defaults",
+			query:        "q=black+friday",
+			expectedCode: http.StatusOK,
+			expected:     models.BannerSearch{Query: "black friday", Limit: 20},
+		},
+		{
+			name:         "filters",
+			query:        "q=%22black+friday%22+-sale&tag_id=2&feature_id=3&limit=5",
+			expectedCode: http.StatusOK,
+			expected:     models.BannerSearch{Query: `"black friday" -sale`, TagID: 2, FeatureID: 3, Limit: 5},
+		},
+		{name: "no query", query: "q=+", expectedCode: http.StatusBadRequest},
+		{name: "long query", query: "q=" + strings.Repeat("a", 201), expectedCode: http.StatusBadRequest},
+		{name: "incorrect tag", query: "q=a&tag_id=x", expectedCode: http.StatusBadRequest},
+		{name: "limit too large", query: "q=a&limit=1000", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &searchRepository{}
+			bh := bannerHandler.NewBannerHandler(bannerService.NewBannerService(repo, nil), logrus.New())
+
+			w := httptest.NewRecorder()
+			bh.SearchBanners(w, httptest.NewRequest(http.MethodGet, "/api/banner/search?"+tc.query, nil))
+
+			if e, a := tc.expectedCode, w.Code; e != a {
+				t.Fatalf("expected status code: %v, got status code: %v: %s", e, a, w.Body.String())
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			if e, a := tc.expected, repo.search; e != a {
+				t.Errorf("expected search: %+v, got: %+v", e, a)
+			}
+			if e, a := "[]", strings.TrimSpace(w.Body.String()); e != a {
+				t.Errorf("expected an empty array, got %s", a)
+			}
+		})
+	}
+}
+
+func Test_searchBanners(t *testing.T) {
+	testDB, err := db.Open()
+	if err != nil {
+		t.Fatalf("error to connect: %v", err)
+	}
+	defer func() {
+		if err := db.Truncate(testDB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+		testDB.Close()
+	}()
+
+	if _, err = db.SeedFeatures(testDB); err != nil {
+		t.Fatalf("error seeding features: %v", err)
+	}
+	if _, err = db.SeedTags(testDB); err != nil {
+		t.Fatalf("error seeding tags: %v", err)
+	}
+
+	br := bannerRepository.NewBannerRepository(testDB)
+	active := models.NullBool{IsTrue: true, HasValue: true}
+	results, err := br.CreateBanners(context.Background(), []models.BannerPayload{
+		{TagIDs: []int{1}, FeatureID: 1, IsActive: active,
+			Content: []byte(`{"title":"Spring sale","text":"Black Friday prices in April","url":"https://a.example"}`)},
+		{TagIDs: []int{2}, FeatureID: 2, IsActive: active,
+			Content: []byte(`{"title":"Black Friday","text":"Everything half price","url":"https://b.example"}`)},
+		{TagIDs: []int{3}, FeatureID: 3, IsActive: active,
+			Content: []byte(`{"title":"Cyber Monday","text":"Friday is over","url":"https://c.example"}`)},
+	}, true)
+	if err != nil {
+		t.Fatalf("error creating banners: %v", err)
+	}
+
+	found, err := br.SearchBanners(context.Background(), models.BannerSearch{Query: `"black friday"`, Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := 2, len(found); e != a {
+		t.Fatalf("expected %d banners, got %d: %+v", e, a, found)
+	}
+	// A match in the title weighs more than one in the text.
+	if e, a := results[1].BannerID, found[0].BannerID; e != a {
+		t.Errorf("expected banner %d first, got %d", e, a)
+	}
+	if e, a := "<mark>Black</mark> <mark>Friday</mark>", found[0].Highlights["title"]; e != a {
+		t.Errorf("expected title highlight: %q, got: %q", e, a)
+	}
+	if _, ok := found[0].Highlights["text"]; ok {
+		t.Errorf("expected no text highlight without a match, got %+v", found[0].Highlights)
+	}
+
+	found, err = br.SearchBanners(context.Background(), models.BannerSearch{Query: "friday", TagID: 3, Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(found) != 1 || found[0].BannerID != results[2].BannerID {
+		t.Errorf("expected only banner %d with tag 3, got %+v", results[2].BannerID, found)
+	}
+
+	// The index follows updates of the content.
+	_, err = testDB.Exec(context.Background(), `UPDATE banner SET content = $1 WHERE banner_id = $2`,
+		[]byte(`{"title":"Winter sale"}`), results[1].BannerID)
+	if err != nil {
+		t.Fatalf("error updating banner: %v", err)
+	}
+	found, err = br.SearchBanners(context.Background(), models.BannerSearch{Query: "winter", Limit: 10})
+	if err != nil || len(found) != 1 || found[0].BannerID != results[1].BannerID {
+		t.Errorf("expected the updated banner, got %+v: %v", found, err)
+	}
+}
@@ -0,0 +1,160 @@
+package tests_test
+
+import (
+	"banner-service/internal/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	authHandler "banner-service/internal/pkg/auth/http"
+	authRepository "banner-service/internal/pkg/auth/repository"
+	authService "banner-service/internal/pkg/auth/sevice"
+	"banner-service/internal/pkg/ratelimit"
+	"banner-service/internal/utils/jwter"
+)
+
+type stubAuthRepository struct {
+	users      map[string]models.User
+	failures   []string
+	failureErr error
+}
+
+func (r *stubAuthRepository) CreateUser(_ context.Context, u *models.User) (int, error) {
+	u.UserID = len(r.users) + 1
+	r.users[u.Login] = *u
+	return u.UserID, nil
+}
+
+func (r *stubAuthRepository) ReadUserByLogin(_ context.Context, login string) (models.User, error) {
+	u, ok := r.users[login]
+	if !ok {
+		return models.User{}, authRepository.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (r *stubAuthRepository) CreateSignInFailure(_ context.Context, login, ip string) error {
+	if r.failureErr != nil {
+		return r.failureErr
+	}
+	r.failures = append(r.failures, login+"@"+ip)
+	return nil
+}
+
+func (r *stubAuthRepository) UpsertExternalUser(_ context.Context, u *models.User) (int, error) {
+	if existing, ok := r.users[u.Login]; ok {
+		u.UserID = existing.UserID
+	} else {
+		u.UserID = len(r.users) + 1
+	}
+	r.users[u.Login] = *u
+	return u.UserID, nil
+}
+
+func Test_signInRateLimit(t *testing.T) {
+	logger := logrus.New()
+
+	tests := []struct {
+		Name             string
+		Limits           ratelimit.SignInLimits
+		FailureErr       error
+		Attempts         []string
+		ExpectedCodes    []int
+		ExpectedFailures int
+	}{
+		{
+			Name: "Too many attempts for login",
+			Limits: ratelimit.SignInLimits{
+				LoginAttempts: 2,
+				IPAttempts:    10,
+				Window:        time.Minute,
+			},
+			Attempts:         []string{"wrong", "wrong", "6789"},
+			ExpectedCodes:    []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests},
+			ExpectedFailures: 2,
+		},
+		{
+			Name: "Account locked after failures",
+			Limits: ratelimit.SignInLimits{
+				LoginAttempts:    10,
+				IPAttempts:       10,
+				Window:           time.Minute,
+				LockoutThreshold: 2,
+				LockoutDuration:  time.Minute,
+			},
+			Attempts:         []string{"wrong", "wrong", "6789"},
+			ExpectedCodes:    []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests},
+			ExpectedFailures: 2,
+		},
+		{
+			Name: "Success resets failures",
+			Limits: ratelimit.SignInLimits{
+				LoginAttempts:    10,
+				IPAttempts:       10,
+				Window:           time.Minute,
+				LockoutThreshold: 2,
+				LockoutDuration:  time.Minute,
+			},
+			Attempts:         []string{"wrong", "6789", "wrong", "6789"},
+			ExpectedCodes:    []int{http.StatusUnauthorized, http.StatusOK, http.StatusUnauthorized, http.StatusOK},
+			ExpectedFailures: 2,
+		},
+		{
+			Name: "Account locked when failures cannot be logged",
+			Limits: ratelimit.SignInLimits{
+				LoginAttempts:    10,
+				IPAttempts:       10,
+				Window:           time.Minute,
+				LockoutThreshold: 2,
+				LockoutDuration:  time.Minute,
+			},
+			FailureErr:       errors.New("sign_in_failure is unavailable"),
+			Attempts:         []string{"wrong", "wrong", "6789"},
+			ExpectedCodes:    []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusTooManyRequests},
+			ExpectedFailures: 0,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			repo := &stubAuthRepository{users: map[string]models.User{
+				"admin": {UserID: 1, Login: "admin", Password: "6789", IsAdmin: true, TagID: 1},
+			}, failureErr: test.FailureErr}
+			guard := ratelimit.NewSignInGuard(ratelimit.NewMemoryStore(), test.Limits)
+			ah := authHandler.NewAuthHandler(authService.NewAuthService(repo, guard, logger), logger,
+				jwter.New("secret", time.Hour))
+
+			for i, password := range test.Attempts {
+				var b bytes.Buffer
+				if err := json.NewEncoder(&b).Encode(models.User{Login: "admin", Password: password}); err != nil {
+					t.Errorf("error encoding request body: %v", err)
+				}
+
+				req := httptest.NewRequest(http.MethodPost, "/sign_in", &b)
+				w := httptest.NewRecorder()
+				ah.SignIn(w, req)
+
+				if e, a := test.ExpectedCodes[i], w.Code; e != a {
+					t.Errorf("attempt %d: expected status code: %v, got status code: %v", i, e, a)
+				}
+
+				if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+					t.Errorf("attempt %d: expected Retry-After header", i)
+				}
+			}
+
+			if e, a := test.ExpectedFailures, len(repo.failures); e != a {
+				t.Errorf("expected logged failures: %v, got logged failures: %v", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
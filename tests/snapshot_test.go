@@ -0,0 +1,187 @@
+package tests_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/models"
+	bannerHandler "banner-service/internal/pkg/banner/http"
+	bannerService "banner-service/internal/pkg/banner/service"
+	"banner-service/internal/pkg/snapshot"
+)
+
+func newSnapshotKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	return public, private
+}
+
+func writePEM(t *testing.T, path, kind string, der []byte, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("error marshalling key: %v", err)
+	}
+	if err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_snapshotSignature(t *testing.T) {
+	public, private := newSnapshotKey(t)
+	snap := models.BannerSnapshot{Version: 7, Banners: []models.SnapshotBanner{
+		{TagID: 1, FeatureID: 2, Content: json.RawMessage(`{"title":"a"}`)},
+	}}
+
+	data, err := snapshot.Sign(snap, private)
+	if err != nil {
+		t.Fatalf("error signing snapshot: %v", err)
+	}
+	verified, err := snapshot.Verify(data, public)
+	if err != nil {
+		t.Fatalf("error verifying snapshot: %v", err)
+	}
+	if verified.Version != 7 || len(verified.Banners) != 1 || string(verified.Banners[0].Content) != `{"title":"a"}` {
+		t.Errorf("unexpected snapshot: %+v", verified)
+	}
+
+	tampered := []byte(strings.Replace(string(data), `"title":"a"`, `"title":"b"`, 1))
+	if _, err = snapshot.Verify(tampered, public); !errors.Is(err, snapshot.ErrSignature) {
+		t.Errorf("expected %v for changed content, got %v", snapshot.ErrSignature, err)
+	}
+	other, _ := newSnapshotKey(t)
+	if _, err = snapshot.Verify(data, other); !errors.Is(err, snapshot.ErrSignature) {
+		t.Errorf("expected %v for another key, got %v", snapshot.ErrSignature, err)
+	}
+	future := []byte(strings.Replace(string(data), `"format":1`, `"format":2`, 1))
+	if _, err = snapshot.Verify(future, public); !errors.Is(err, snapshot.ErrFormat) {
+		t.Errorf("expected %v for format 2, got %v", snapshot.ErrFormat, err)
+	}
+
+	dir := t.TempDir()
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	writePEM(t, filepath.Join(dir, "private.pem"), "PRIVATE KEY", der, err)
+	der, err = x509.MarshalPKIXPublicKey(public)
+	writePEM(t, filepath.Join(dir, "public.pem"), "PUBLIC KEY", der, err)
+
+	loadedPrivate, err := snapshot.LoadPrivateKey(filepath.Join(dir, "private.pem"))
+	if err != nil || !loadedPrivate.Equal(private) {
+		t.Errorf("expected the private key back, got %v", err)
+	}
+	loadedPublic, err := snapshot.LoadPublicKey(filepath.Join(dir, "public.pem"))
+	if err != nil || !loadedPublic.Equal(public) {
+		t.Errorf("expected the public key back, got %v", err)
+	}
+}
+
+func Test_snapshotTable(t *testing.T) {
+	table := snapshot.NewTable()
+	if err := table.Ping(context.Background()); !errors.Is(err, snapshot.ErrNotLoaded) {
+		t.Errorf("expected %v before the first snapshot, got %v", snapshot.ErrNotLoaded, err)
+	}
+
+	snap := func(version int64, content string) models.BannerSnapshot {
+		return models.BannerSnapshot{Version: version, Banners: []models.SnapshotBanner{
+			{TagID: 1, FeatureID: 1, Content: json.RawMessage(content)},
+		}}
+	}
+	if !table.Swap(snap(2, `{"v":2}`)) {
+		t.Fatal("expected the first snapshot to load")
+	}
+	if table.Swap(snap(2, `{"v":3}`)) || table.Swap(snap(1, `{"v":1}`)) {
+		t.Error("expected the same and an older version to be ignored")
+	}
+	if content, ok := table.Lookup(1, 1); !ok || string(content) != `{"v":2}` {
+		t.Errorf("expected content of version 2, got %s", content)
+	}
+	if _, ok := table.Lookup(1, 2); ok {
+		t.Error("expected no banner for feature 2")
+	}
+	if err := table.Ping(context.Background()); err != nil || table.Version() != 2 {
+		t.Errorf("expected version 2 to be ready, got %d, %v", table.Version(), err)
+	}
+}
+
+func Test_snapshotEdge(t *testing.T) {
+	public, private := newSnapshotKey(t)
+	repo := &transferRepository{records: []models.BannerRecord{
+		{Banner: models.Banner{
+			BannerID: 1, TagIDs: []int{2, 1}, FeatureID: 3, Content: json.RawMessage(`{"title":"on"}`), IsActive: true,
+		}},
+		{Banner: models.Banner{
+			BannerID: 2, TagIDs: []int{4}, FeatureID: 3, Content: json.RawMessage(`{"title":"off"}`), IsActive: false,
+		}},
+	}}
+
+	w := httptest.NewRecorder()
+	newTransferHandler(repo).GetSnapshot(w, httptest.NewRequest(http.MethodGet, "/api/banner/snapshot", nil))
+	if e, a := http.StatusNotFound, w.Code; e != a {
+		t.Errorf("expected status code without a key: %v, got status code: %v", e, a)
+	}
+
+	w = httptest.NewRecorder()
+	newTransferHandler(repo).WithSnapshotKey(private).
+		GetSnapshot(w, httptest.NewRequest(http.MethodGet, "/api/banner/snapshot", nil))
+	if e, a := http.StatusOK, w.Code; e != a {
+		t.Fatalf("expected status code: %v, got status code: %v: %s", e, a, w.Body.String())
+	}
+	snap, err := snapshot.Verify(w.Body.Bytes(), public)
+	if err != nil {
+		t.Fatalf("error verifying snapshot: %v", err)
+	}
+	if len(snap.Banners) != 2 || snap.Banners[0].TagID != 1 || snap.Banners[1].TagID != 2 {
+		t.Errorf("expected the active banner once per tag, got %+v", snap.Banners)
+	}
+	if w.Header().Get("X-Snapshot-Version") == "" {
+		t.Error("expected the snapshot version header")
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err = os.WriteFile(path, w.Body.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	table := snapshot.NewTable()
+	if err = snapshot.NewPoller(path, public, table, "", "", logger).Poll(context.Background()); err != nil {
+		t.Fatalf("error polling snapshot: %v", err)
+	}
+
+	other, _ := newSnapshotKey(t)
+	if err = snapshot.NewPoller(path, other, table, "", "", logger).Poll(context.Background()); err == nil {
+		t.Error("expected a snapshot signed with another key to be rejected")
+	}
+
+	edge := bannerHandler.NewUserBannerHandler(bannerService.NewEdgeService(table), logger)
+	get := func(query string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/user_banner?"+query, nil)
+		r = r.WithContext(context.WithValue(r.Context(), "is_admin", true))
+		w := httptest.NewRecorder()
+		edge.GetBanner(w, r)
+		return w
+	}
+	if w = get("tag_id=2&feature_id=3"); w.Code != http.StatusOK || w.Body.String() != `{"title":"on"}` {
+		t.Errorf("expected the active banner from the edge, got %v: %s", w.Code, w.Body.String())
+	}
+	if w = get("tag_id=4&feature_id=3"); w.Code != http.StatusNotFound {
+		t.Errorf("expected status code for an inactive banner: %v, got status code: %v", http.StatusNotFound, w.Code)
+	}
+}
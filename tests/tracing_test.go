@@ -0,0 +1,83 @@
+package tests_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"banner-service/internal/pkg/banner"
+	bannerHandler "banner-service/internal/pkg/banner/http"
+	bannerService "banner-service/internal/pkg/banner/service"
+	"banner-service/internal/pkg/middleware"
+)
+
+type userBannerRepository struct {
+	banner.BannerRepository
+	content []byte
+}
+
+func (r *userBannerRepository) ReadUserBanner(_ context.Context, _, _ int) ([]byte, error) {
+	return r.content, nil
+}
+
+func Test_tracingSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	bs := bannerService.NewBannerService(&userBannerRepository{content: []byte(`{"title":"t"}`)}, nil)
+	bh := bannerHandler.NewBannerHandler(bs, logrus.New())
+
+	r := mux.NewRouter()
+	r.Use(middleware.Trace)
+	r.HandleFunc("/api/user_banner", func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), "is_admin", false)
+		ctx = context.WithValue(ctx, "tag_id", 1)
+		bh.GetBanner(w, r.WithContext(ctx))
+	}).Name("user_banner")
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/api/user_banner?tag_id=1&feature_id=1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if e, a := http.StatusOK, w.Code; e != a {
+		t.Fatalf("expected status code: %v, got status code: %v", e, a)
+	}
+
+	spans := recorder.Ended()
+	parents := make(map[string]string, len(spans))
+	ids := make(map[string]string, len(spans))
+	for _, s := range spans {
+		if e, a := traceID, s.SpanContext().TraceID().String(); e != a {
+			t.Errorf("span %s: expected trace id: %v, got trace id: %v", s.Name(), e, a)
+		}
+		ids[s.SpanContext().SpanID().String()] = s.Name()
+		parents[s.Name()] = s.Parent().SpanID().String()
+	}
+
+	expected := map[string]string{
+		"BannerService.GetBanner": "BannerHandler.GetBanner",
+		"BannerHandler.GetBanner": "GET user_banner",
+	}
+	for child, parent := range expected {
+		if a := ids[parents[child]]; a != parent {
+			t.Errorf("expected parent of %s: %v, got: %v", child, parent, a)
+		}
+	}
+
+	if w.Header().Get("traceparent") == "" {
+		t.Errorf("expected traceparent in response")
+	}
+}
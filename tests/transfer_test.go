@@ -0,0 +1,241 @@
+package tests_test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"banner-service/internal/models"
+	"banner-service/internal/pkg/banner"
+	bannerHandler "banner-service/internal/pkg/banner/http"
+	bannerService "banner-service/internal/pkg/banner/service"
+)
+
+type transferRepository struct {
+	banner.BannerRepository
+	records  []models.BannerRecord
+	imported []models.ImportLine
+	opts     models.ImportOptions
+}
+
+func (r *transferRepository) ExportBanners(_ context.Context, _ bool, fn func(models.BannerRecord) error) error {
+	for _, rec := range r.records {
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *transferRepository) ImportBanners(_ context.Context, lines []models.ImportLine,
+	opts models.ImportOptions) (models.ImportReport, error) {
+	r.imported, r.opts = lines, opts
+
+	report := models.ImportReport{Atomic: opts.Atomic, Succeeded: len(lines)}
+	if !opts.DryRun {
+		report.Committed = len(lines)
+	}
+	return report, nil
+}
+
+func newTransferHandler(repo *transferRepository) *bannerHandler.BannerHandler {
+	return bannerHandler.NewBannerHandler(bannerService.NewBannerService(repo, nil), logrus.New())
+}
+
+func Test_exportBanners(t *testing.T) {
+	created := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	repo := &transferRepository{records: []models.BannerRecord{
+		{
+			Banner: models.Banner{
+				BannerID: 1, TagIDs: []int{1, 2}, FeatureID: 3, Content: json.RawMessage(`{"title":"a, b"}`),
+				IsActive: true, CreatedAt: created, UpdatedAt: created,
+			},
+			Version: 2,
+		},
+		{
+			Banner:  models.Banner{BannerID: 2, TagIDs: []int{4}, FeatureID: 5, Content: json.RawMessage(`{}`)},
+			Version: 1,
+		},
+	}}
+	bh := newTransferHandler(repo)
+
+	t.Run("jsonl", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		bh.ExportBanners(w, httptest.NewRequest(http.MethodGet, "/api/banner/export", nil))
+
+		if e, a := http.StatusOK, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+		if e, a := "application/x-ndjson", w.Header().Get("Content-Type"); e != a {
+			t.Errorf("expected content type: %v, got: %v", e, a)
+		}
+
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if e, a := 2, len(lines); e != a {
+			t.Fatalf("expected %d lines, got %d: %s", e, a, w.Body.String())
+		}
+		var rec models.BannerRecord
+		if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.BannerID != 1 || rec.Version != 2 || len(rec.TagIDs) != 2 || string(rec.Content) != `{"title":"a, b"}` {
+			t.Errorf("unexpected record: %+v", rec)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		bh.ExportBanners(w, httptest.NewRequest(http.MethodGet, "/api/banner/export?format=csv", nil))
+
+		if e, a := http.StatusOK, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+
+		rows, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e, a := 3, len(rows); e != a {
+			t.Fatalf("expected %d rows, got %d", e, a)
+		}
+		if e, a := "banner_id", rows[0][0]; e != a {
+			t.Errorf("expected header to start with %v, got %v", e, a)
+		}
+		if e, a := "1,2", rows[1][1]; e != a {
+			t.Errorf("expected tag_ids: %v, got: %v", e, a)
+		}
+		if e, a := `{"title":"a, b"}`, rows[1][7]; e != a {
+			t.Errorf("expected content: %v, got: %v", e, a)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		bh.ExportBanners(w, httptest.NewRequest(http.MethodGet, "/api/banner/export?format=xml", nil))
+
+		if e, a := http.StatusBadRequest, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+	})
+}
+
+func Test_importBanners(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+
+		expectedCode     int
+		expectedImported int
+		expectedDryRun   bool
+		expectedErrLines []int
+	}{
+		{
+			name:   "jsonl",
+			target: "/api/banner/import",
+			body: `{"tag_ids":[1],"feature_id":1,"content":{"title":"a"}}
+
+{"tag_ids":[2],"feature_id":1,"content":{"title":"b"},"is_active":false}
+`,
+			expectedCode:     http.StatusOK,
+			expectedImported: 2,
+		},
+		{
+			name:   "jsonl with invalid lines is a dry run in atomic mode",
+			target: "/api/banner/import",
+			body: `{"tag_ids":[1],"feature_id":1,"content":{"title":"a"}}
+{"tag_ids":[1],"feature_id":1,
+{"tag_ids":[],"feature_id":1,"content":{}}
+`,
+			expectedCode:     http.StatusUnprocessableEntity,
+			expectedImported: 1,
+			expectedDryRun:   true,
+			expectedErrLines: []int{2, 3},
+		},
+		{
+			name:             "chunked import keeps valid lines",
+			target:           "/api/banner/import?mode=chunked&chunk_size=10",
+			body:             "{\"tag_ids\":[1],\"feature_id\":1,\"content\":{}}\n{\"tag_ids\":[1]}\n",
+			expectedCode:     http.StatusUnprocessableEntity,
+			expectedImported: 1,
+			expectedErrLines: []int{2},
+		},
+		{
+			name:        "csv",
+			target:      "/api/banner/import",
+			contentType: "text/csv",
+			body: "tag_ids,feature_id,content,is_active\n" +
+				"\"1,2\",3,\"{\"\"title\"\":\"\"a\"\"}\",true\n" +
+				"x,3,{},true\n" +
+				"1,4,{},false\n",
+			expectedCode:     http.StatusUnprocessableEntity,
+			expectedImported: 2,
+			expectedDryRun:   true,
+			expectedErrLines: []int{3},
+		},
+		{
+			name:         "csv without required column",
+			target:       "/api/banner/import?format=csv",
+			body:         "tag_ids,content\n1,{}\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown mode",
+			target:       "/api/banner/import?mode=all",
+			body:         "",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &transferRepository{}
+			bh := newTransferHandler(repo)
+
+			req := httptest.NewRequest(http.MethodPost, tc.target, strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			w := httptest.NewRecorder()
+			bh.ImportBanners(w, req)
+
+			if e, a := tc.expectedCode, w.Code; e != a {
+				t.Fatalf("expected status code: %v, got status code: %v: %s", e, a, w.Body.String())
+			}
+			if tc.expectedCode == http.StatusBadRequest {
+				return
+			}
+
+			if e, a := tc.expectedImported, len(repo.imported); e != a {
+				t.Errorf("expected %d lines sent to the repository, got %d", e, a)
+			}
+			if e, a := tc.expectedDryRun, repo.opts.DryRun; e != a {
+				t.Errorf("expected repository dry run: %v, got: %v", e, a)
+			}
+
+			var report models.ImportReport
+			if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatal(err)
+			}
+			if e, a := len(tc.expectedErrLines), len(report.Errors); e != a {
+				t.Fatalf("expected %d errors, got %d: %+v", e, a, report.Errors)
+			}
+			for i, line := range tc.expectedErrLines {
+				if e, a := line, report.Errors[i].Line; e != a {
+					t.Errorf("expected error on line %d, got line %d", e, a)
+				}
+			}
+			if report.DryRun {
+				t.Errorf("expected report to keep the requested dry run")
+			}
+		})
+	}
+}